@@ -0,0 +1,230 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultAsyncQueueSize is the default number of span events queued by WithAsyncEvents.
+const defaultAsyncQueueSize = 1024
+
+// OverflowPolicy controls what happens when the queue of asynchronously recorded span events is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the logging goroutine until the queue has room.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the span event of the record being logged.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest queued span event to make room.
+	OverflowDropOldest
+)
+
+// AsyncOptions configures the asynchronous recording of span events.
+type AsyncOptions struct {
+	// QueueSize is the maximum number of queued span events. It defaults to 1024.
+	QueueSize int
+
+	// Overflow is the policy applied when the queue is full. It defaults to OverflowBlock.
+	Overflow OverflowPolicy
+}
+
+// eventJob is a queued unit of work. release is called once the job has run or was dropped.
+type eventJob struct {
+	run     func()
+	release func()
+}
+
+// eventQueue records span events on a single worker goroutine, in the order they were logged.
+// It is shared by all handlers derived from the same Handler.
+type eventQueue struct {
+	mu       sync.RWMutex
+	closed   bool
+	overflow OverflowPolicy
+	jobs     chan eventJob
+	done     chan struct{}
+	dropped  func()
+}
+
+// newEventQueue creates an event queue and starts its worker.
+// dropped is called for every span event dropped because the queue is full.
+func newEventQueue(opts AsyncOptions, dropped func()) *eventQueue {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultAsyncQueueSize
+	}
+
+	q := &eventQueue{
+		overflow: opts.Overflow,
+		jobs:     make(chan eventJob, opts.QueueSize),
+		done:     make(chan struct{}),
+		dropped:  dropped,
+	}
+	go q.work()
+	return q
+}
+
+// work runs the queued jobs until the queue is shut down.
+func (q *eventQueue) work() {
+	defer close(q.done)
+
+	for job := range q.jobs {
+		job.run()
+		job.release()
+	}
+}
+
+// enqueue queues the job according to the overflow policy. OverflowBlock gives up once ctx is done.
+// It reports false if the queue is shut down or ctx is done first, in which case the job is neither run
+// nor released.
+func (q *eventQueue) enqueue(ctx context.Context, job eventJob, overflow OverflowPolicy) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return false
+	}
+
+	switch overflow {
+	case OverflowDropNewest:
+		select {
+		case q.jobs <- job:
+		default:
+			q.drop(job)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case q.jobs <- job:
+				return true
+			default:
+			}
+			select {
+			case oldest := <-q.jobs:
+				q.drop(oldest)
+			default:
+			}
+		}
+	default:
+		select {
+		case q.jobs <- job:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// drop releases a job that is not run.
+func (q *eventQueue) drop(job eventJob) {
+	job.release()
+	q.dropped()
+}
+
+// flush waits until the jobs queued before the call have run, or the context is done.
+func (q *eventQueue) flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	if !q.enqueue(ctx, eventJob{run: func() { close(flushed) }, release: func() {}}, OverflowBlock) {
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shutdown stops accepting jobs and waits until the queued jobs have run, or the context is done.
+func (q *eventQueue) shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithAsyncEvents records span events on a worker goroutine instead of the logging goroutine.
+// The record is snapshotted with slog.Record.Clone, and its attributes are converted and attached to the span
+// by the worker. Ending a SpanContext waits for its queued events. Spans started elsewhere must only end after
+// Flush, or their queued events may be lost. Call Shutdown before shutting down the TracerProvider.
+// Records are always passed to the next handler, even with WithSuppressRepeats.
+func WithAsyncEvents(opts AsyncOptions) Options {
+	return func(h *Handler) {
+		if h.events != nil {
+			_ = h.events.shutdown(context.Background())
+		}
+		h.events = newEventQueue(opts, h.countDroppedEvent)
+	}
+}
+
+// addSpanEventsAsync queues the span event of the record. It falls back to recording the event synchronously
+// once the queue is shut down or the SpanContext has ended, and reports whether the record should be passed
+// to the next handler.
+func (h *Handler) addSpanEventsAsync(span trace.Span, state *spanState, record *slog.Record) bool {
+	release, ok := state.trackPending()
+	if !ok {
+		return h.addSpanEvents(span, state, record)
+	}
+
+	snapshot := record.Clone()
+	job := eventJob{
+		run:     func() { h.addSpanEvents(span, state, &snapshot) },
+		release: release,
+	}
+	if h.events.enqueue(context.Background(), job, h.events.overflow) {
+		return true
+	}
+
+	release()
+	return h.addSpanEvents(span, state, record)
+}
+
+// Shutdown flushes the records held by WithTraceBuffer, and records the span events queued by WithAsyncEvents
+// before stopping its worker. Span events of later records are recorded synchronously.
+// It is intended to be called before the TracerProvider shuts down.
+func (h *Handler) Shutdown(ctx context.Context) error {
+	err := h.Flush(ctx)
+	if h.events == nil {
+		return err
+	}
+
+	if shutdownErr := h.events.shutdown(ctx); shutdownErr != nil {
+		return shutdownErr
+	}
+	return err
+}
+
+// trackPending counts a queued span event of the span, so that End waits for it. It returns the function
+// releasing the event, and reports false once the span has ended, as End may already be waiting.
+func (s *spanState) trackPending() (func(), bool) {
+	if s == nil || s.pending == nil {
+		return func() {}, true
+	}
+
+	s.owner.mu.Lock()
+	defer s.owner.mu.Unlock()
+
+	if s.owner.ended || s.owner.state != s {
+		return nil, false
+	}
+
+	s.pending.Add(1)
+	return s.pending.Done, true
+}
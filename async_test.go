@@ -0,0 +1,237 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestEventQueue(t *testing.T) {
+	tests := []struct {
+		name     string
+		overflow OverflowPolicy
+		expected []int
+		dropped  int64
+	}{
+		{
+			name:     "drop newest",
+			overflow: OverflowDropNewest,
+			expected: []int{0, 1, 2},
+			dropped:  2,
+		},
+		{
+			name:     "drop oldest",
+			overflow: OverflowDropOldest,
+			expected: []int{0, 3, 4},
+			dropped:  2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var dropped atomic.Int64
+			q := newEventQueue(AsyncOptions{QueueSize: 2, Overflow: test.overflow}, func() { dropped.Add(1) })
+
+			var mu sync.Mutex
+			var ran []int
+			started, gate := make(chan struct{}), make(chan struct{})
+			for i := 0; i < 5; i++ {
+				run := func() {
+					mu.Lock()
+					ran = append(ran, i)
+					mu.Unlock()
+				}
+				if i == 0 {
+					run = func() {
+						close(started)
+						<-gate
+						mu.Lock()
+						ran = append(ran, 0)
+						mu.Unlock()
+					}
+				}
+				assert.True(t, q.enqueue(context.Background(), eventJob{run: run, release: func() {}}, q.overflow))
+				if i == 0 {
+					<-started
+				}
+			}
+			close(gate)
+
+			assert.NoError(t, q.shutdown(context.Background()))
+			assert.Equal(t, test.expected, ran)
+			assert.Equal(t, test.dropped, dropped.Load())
+			assert.False(t, q.enqueue(context.Background(), eventJob{run: func() {}, release: func() {}}, q.overflow))
+		})
+	}
+
+	t.Run("flush deadline", func(t *testing.T) {
+		q := newEventQueue(AsyncOptions{}, func() {})
+		gate := make(chan struct{})
+		q.enqueue(context.Background(), eventJob{run: func() { <-gate }, release: func() {}}, q.overflow)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, q.flush(ctx), context.DeadlineExceeded)
+
+		close(gate)
+		assert.NoError(t, q.flush(context.Background()))
+		assert.NoError(t, q.shutdown(context.Background()))
+	})
+
+	t.Run("flush deadline with a full queue", func(t *testing.T) {
+		q := newEventQueue(AsyncOptions{QueueSize: 1}, func() {})
+		started, gate := make(chan struct{}), make(chan struct{})
+		q.enqueue(context.Background(), eventJob{run: func() { close(started); <-gate }, release: func() {}}, q.overflow)
+		<-started
+		q.enqueue(context.Background(), eventJob{run: func() {}, release: func() {}}, q.overflow)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, q.flush(ctx), context.DeadlineExceeded)
+
+		close(gate)
+		assert.NoError(t, q.shutdown(context.Background()))
+	})
+}
+
+func TestWithAsyncEvents(t *testing.T) {
+	setup := func() *tracetest.SpanRecorder {
+		spanRecorder := tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(trace.NewTracerProvider(trace.WithSpanProcessor(spanRecorder)))
+		return spanRecorder
+	}
+
+	t.Run("span context", func(t *testing.T) {
+		spanRecorder := setup()
+		buf := bytes.NewBuffer(nil)
+		h := NewHandler(slog.NewJSONHandler(buf, nil), WithAsyncEvents(AsyncOptions{}))
+		defer func() { assert.NoError(t, h.Shutdown(context.Background())) }()
+		logger := slog.New(h)
+
+		span := NewSpanContext("span")
+		for i := 0; i < 100; i++ {
+			logger.InfoContext(span, "event", "i", i)
+		}
+		span.End()
+
+		assert.Contains(t, buf.String(), `"trace_id":"`)
+
+		spans := spanRecorder.Ended()
+		if assert.Len(t, spans, 1) && assert.Len(t, spans[0].Events(), 100) {
+			for i, event := range spans[0].Events() {
+				assert.Contains(t, event.Attributes, attribute.Int64("i", int64(i)))
+			}
+		}
+	})
+
+	t.Run("external span", func(t *testing.T) {
+		spanRecorder := setup()
+		h := NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil), WithAsyncEvents(AsyncOptions{}))
+		defer func() { assert.NoError(t, h.Shutdown(context.Background())) }()
+
+		ctx, span := otel.Tracer("test").Start(context.Background(), "span")
+		slog.New(h).InfoContext(ctx, "event", "key", "value")
+		assert.NoError(t, h.Flush(context.Background()))
+		span.End()
+
+		spans := spanRecorder.Ended()
+		if assert.Len(t, spans, 1) && assert.Len(t, spans[0].Events(), 1) {
+			assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String("key", "value"))
+		}
+	})
+
+	t.Run("after shutdown", func(t *testing.T) {
+		spanRecorder := setup()
+		h := NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil), WithAsyncEvents(AsyncOptions{}))
+		assert.NoError(t, h.Shutdown(context.Background()))
+
+		span := NewSpanContext("span")
+		slog.New(h).InfoContext(span, "event")
+		span.End()
+
+		spans := spanRecorder.Ended()
+		if assert.Len(t, spans, 1) {
+			assert.Len(t, spans[0].Events(), 1)
+		}
+	})
+
+	t.Run("after end", func(t *testing.T) {
+		setup()
+		h := NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil), WithAsyncEvents(AsyncOptions{}))
+		defer func() { assert.NoError(t, h.Shutdown(context.Background())) }()
+
+		span := NewSpanContext("span")
+		slog.New(h).InfoContext(span, "event")
+		span.End()
+
+		_, ok := span.current().trackPending()
+		assert.False(t, ok)
+	})
+
+	t.Run("concurrent with end", func(t *testing.T) {
+		setup()
+		h := NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil), WithAsyncEvents(AsyncOptions{}))
+		defer func() { assert.NoError(t, h.Shutdown(context.Background())) }()
+		logger := slog.New(h)
+
+		span := NewSpanContext("span")
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 1; j <= 200; j++ {
+					logger.InfoContext(span, "event", "j", j)
+					if j%50 == 0 {
+						span.End()
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		spanRecorder := setup()
+		h := NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil),
+			WithAsyncEvents(AsyncOptions{QueueSize: 4, Overflow: OverflowDropOldest}))
+		logger := slog.New(h)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				span := NewSpanContext("span")
+				for j := 0; j < 50; j++ {
+					logger.InfoContext(span, "event", "j", j)
+				}
+				span.End()
+			}()
+		}
+		wg.Wait()
+		assert.NoError(t, h.Shutdown(context.Background()))
+
+		var events int64
+		for _, span := range spanRecorder.Ended() {
+			events += int64(len(span.Events()))
+		}
+		assert.Len(t, spanRecorder.Ended(), 8)
+		assert.Equal(t, int64(8*50), events+h.Stats().DroppedEvents)
+	})
+}
@@ -8,8 +8,10 @@ package otelslog
 import (
 	"bytes"
 	"context"
+	"io"
 	"log/slog"
 	"testing"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/sdk/trace"
@@ -127,3 +129,95 @@ func BenchmarkTextOtelSlogWithContext(b *testing.B) {
 		slog.InfoContext(spanCtx, "hello, world")
 	}
 }
+
+// fastPathLoggers returns a plain JSONHandler logger and the same logger wrapped by a Handler.
+func fastPathLoggers() (*slog.Logger, *slog.Logger) {
+	return slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		slog.New(NewHandler(slog.NewJSONHandler(io.Discard, nil)))
+}
+
+// logWithoutSpan logs a record with attributes and no span involved.
+func logWithoutSpan(ctx context.Context, logger *slog.Logger) {
+	logger.InfoContext(ctx, "hello, world",
+		slog.String("string", "value"),
+		slog.Int("int", 1),
+		slog.Bool("bool", true),
+		slog.Duration("duration", time.Second),
+		slog.Any("any", []string{"a", "b"}),
+		slog.Group("group", slog.String("key", "value")))
+}
+
+func TestFastPathAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items randomly with the race detector")
+	}
+
+	ctx := context.Background()
+	plain, wrapped := fastPathLoggers()
+
+	baseline := testing.AllocsPerRun(100, func() { logWithoutSpan(ctx, plain) })
+	allocs := testing.AllocsPerRun(100, func() { logWithoutSpan(ctx, wrapped) })
+	if allocs > baseline {
+		t.Errorf("allocs/op = %v, want at most %v of the plain JSONHandler", allocs, baseline)
+	}
+
+	plain, wrapped = plain.With("key", "value").WithGroup("request"), wrapped.With("key", "value").WithGroup("request")
+	baseline = testing.AllocsPerRun(100, func() { logWithoutSpan(ctx, plain) })
+	allocs = testing.AllocsPerRun(100, func() { logWithoutSpan(ctx, wrapped) })
+	if allocs > baseline {
+		t.Errorf("with attrs and groups: allocs/op = %v, want at most %v of the plain JSONHandler", allocs, baseline)
+	}
+}
+
+func BenchmarkJSONSlogWithoutSpan(b *testing.B) {
+	plain, _ := fastPathLoggers()
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		logWithoutSpan(ctx, plain)
+	}
+}
+
+func BenchmarkJSONOtelSlogWithoutSpan(b *testing.B) {
+	_, wrapped := fastPathLoggers()
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		logWithoutSpan(ctx, wrapped)
+	}
+}
+
+func BenchmarkJSONOtelSlogDeepGroups(b *testing.B) {
+	setUpBenchmarkTracer()
+	logger := slog.New(NewHandler(slog.NewJSONHandler(io.Discard, nil)))
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		logger = logger.WithGroup(name).With("key", name)
+	}
+	span := NewSpanContext("span")
+	defer span.End()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		logWithoutSpan(span, logger)
+	}
+}
+
+func BenchmarkJSONOtelSlogParallelEvents(b *testing.B) {
+	setUpBenchmarkTracer()
+	logger := slog.New(NewHandler(slog.NewJSONHandler(io.Discard, nil))).With("service", "benchmark")
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		span := NewSpanContext("span")
+		defer span.End()
+		for pb.Next() {
+			logWithoutSpan(span, logger)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SuppressedCountKey is the attribute key of the number of suppressed log events
+// recorded on the summary event when a span with an event budget ends.
+const SuppressedCountKey = "suppressed_count"
+
+// EventBudget limits the log events recorded on a single span started by the handler.
+// A zero field disables the corresponding limit. Records that exceed the budget are still
+// passed to the next handler; only the span event is suppressed.
+type EventBudget struct {
+	// MaxEvents is the maximum number of log events recorded on a span.
+	MaxEvents int
+
+	// Rate is the number of log events per second recorded on a span, with bursts of up to Burst events.
+	// Burst defaults to 1 when Rate is set.
+	Rate  float64
+	Burst int
+
+	// SampleEvery records only one in every SampleEvery records below SampleLevel.
+	// SampleLevel defaults to slog.LevelWarn when SampleEvery is set.
+	SampleEvery int
+	SampleLevel slog.Leveler
+}
+
+// enabled reports whether any limit of the budget is set.
+func (b EventBudget) enabled() bool {
+	return b.MaxEvents > 0 || b.Rate > 0 || b.SampleEvery > 1
+}
+
+// spanBudget tracks the event budget of a single span.
+type spanBudget struct {
+	mu         sync.Mutex
+	budget     EventBudget
+	recorded   int
+	sampled    int
+	tokens     float64
+	last       time.Time
+	suppressed int64
+}
+
+// newSpanBudget creates the budget state for a span.
+func newSpanBudget(budget EventBudget) *spanBudget {
+	if budget.Rate > 0 && budget.Burst <= 0 {
+		budget.Burst = 1
+	}
+	if budget.SampleEvery > 1 && budget.SampleLevel == nil {
+		budget.SampleLevel = slog.LevelWarn
+	}
+
+	return &spanBudget{
+		budget: budget,
+		tokens: float64(budget.Burst),
+		last:   time.Now(),
+	}
+}
+
+// allow reports whether a record of the given level may be recorded as a span event.
+// Suppressed records are counted for the summary event.
+func (b *spanBudget) allow(level slog.Level) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.budget.SampleEvery > 1 && level < b.budget.SampleLevel.Level() {
+		b.sampled++
+		if (b.sampled-1)%b.budget.SampleEvery != 0 {
+			b.suppressed++
+			return false
+		}
+	}
+
+	if b.budget.MaxEvents > 0 && b.recorded >= b.budget.MaxEvents {
+		b.suppressed++
+		return false
+	}
+
+	if b.budget.Rate > 0 {
+		now := time.Now()
+		b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.budget.Rate, float64(b.budget.Burst))
+		b.last = now
+		if b.tokens < 1 {
+			b.suppressed++
+			return false
+		}
+		b.tokens--
+	}
+
+	b.recorded++
+	return true
+}
+
+// summarize records a summary event on the span if any log events were suppressed.
+func (b *spanBudget) summarize(span trace.Span, name string) {
+	b.mu.Lock()
+	suppressed := b.suppressed
+	b.mu.Unlock()
+
+	if suppressed > 0 {
+		span.AddEvent(name, trace.WithAttributes(attribute.Int64(SuppressedCountKey, suppressed)))
+	}
+}
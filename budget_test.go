@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpanBudget(t *testing.T) {
+	tests := []struct {
+		name       string
+		budget     EventBudget
+		levels     []slog.Level
+		expected   []bool
+		suppressed int64
+	}{
+		{
+			name:       "max events",
+			budget:     EventBudget{MaxEvents: 2},
+			levels:     []slog.Level{slog.LevelInfo, slog.LevelInfo, slog.LevelError},
+			expected:   []bool{true, true, false},
+			suppressed: 1,
+		},
+		{
+			name:       "rate",
+			budget:     EventBudget{Rate: 0.001, Burst: 2},
+			levels:     []slog.Level{slog.LevelInfo, slog.LevelInfo, slog.LevelInfo},
+			expected:   []bool{true, true, false},
+			suppressed: 1,
+		},
+		{
+			name:       "sampling",
+			budget:     EventBudget{SampleEvery: 2},
+			levels:     []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelInfo, slog.LevelWarn, slog.LevelError},
+			expected:   []bool{true, false, true, true, true},
+			suppressed: 1,
+		},
+		{
+			name:       "sampling level",
+			budget:     EventBudget{SampleEvery: 3, SampleLevel: slog.LevelInfo},
+			levels:     []slog.Level{slog.LevelDebug, slog.LevelDebug, slog.LevelInfo, slog.LevelDebug},
+			expected:   []bool{true, false, true, false},
+			suppressed: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			budget := newSpanBudget(test.budget)
+			allowed := make([]bool, 0, len(test.levels))
+			for _, level := range test.levels {
+				allowed = append(allowed, budget.allow(level))
+			}
+			assert.Equal(t, test.expected, allowed)
+			assert.Equal(t, test.suppressed, budget.suppressed)
+		})
+	}
+}
+
+func TestHandlerEventBudget(t *testing.T) {
+	spanRecorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(trace.NewTracerProvider(trace.WithSpanProcessor(spanRecorder)))
+
+	buf := bytes.NewBuffer(nil)
+	logger := slog.New(NewHandler(slog.NewJSONHandler(buf, nil), WithEventBudget(EventBudget{MaxEvents: 3})))
+
+	spanCtx := NewSpanContext("span", "trace")
+	for i := 0; i < 10; i++ {
+		logger.InfoContext(spanCtx, "loop", "i", i)
+	}
+	spanCtx.End()
+
+	assert.Equal(t, 10, bytes.Count(buf.Bytes(), []byte(`"msg":"loop"`)))
+
+	spans := spanRecorder.Ended()
+
+	assert.Equal(t, 1, len(spans))
+	events := spans[0].Events()
+	assert.Equal(t, 4, len(events))
+	assert.Equal(t, "log.suppressed", events[3].Name)
+	assert.Equal(t, []attribute.KeyValue{attribute.Int64(SuppressedCountKey, 7)}, events[3].Attributes)
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultBufferMaxRecords = 256
+	defaultBufferMaxTraces  = 1024
+	defaultBufferTTL        = time.Minute
+)
+
+// BufferOptions configures tail-based buffering of log records per trace.
+// Records of a trace below Level are held back until a record at or above FlushLevel
+// occurs in the same trace, and are discarded if the root SpanContext of the trace ends first.
+type BufferOptions struct {
+	// Level is the level below which records are buffered. It defaults to slog.LevelWarn.
+	Level slog.Leveler
+
+	// FlushLevel is the level at which a record flushes the buffered records of its trace.
+	// Once flushed, later records of the trace are no longer buffered. It defaults to slog.LevelError.
+	FlushLevel slog.Leveler
+
+	// MaxRecords is the maximum number of records buffered per trace; the oldest records are dropped.
+	// It defaults to 256.
+	MaxRecords int
+
+	// MaxTraces is the maximum number of traces buffered at once; the oldest trace is discarded.
+	// It defaults to 1024.
+	MaxTraces int
+
+	// TTL is how long a trace is buffered after its first record before it is discarded.
+	// It defaults to one minute.
+	TTL time.Duration
+}
+
+// bufferedRecord is a record held back together with the handler and context it was logged with.
+type bufferedRecord struct {
+	handler *Handler
+	ctx     context.Context
+	record  slog.Record
+}
+
+// traceBuffer holds the buffered records of a single trace.
+type traceBuffer struct {
+	elem    *list.Element
+	created time.Time
+	records []bufferedRecord
+	flushed bool
+}
+
+// recordBuffer buffers log records per trace. It is shared by all handlers derived from the same Handler.
+type recordBuffer struct {
+	mu     sync.Mutex
+	opts   BufferOptions
+	traces map[trace.TraceID]*traceBuffer
+	order  *list.List
+	now    func() time.Time
+}
+
+// newRecordBuffer creates a record buffer, applying the defaults of the options.
+func newRecordBuffer(opts BufferOptions) *recordBuffer {
+	if opts.Level == nil {
+		opts.Level = slog.LevelWarn
+	}
+	if opts.FlushLevel == nil {
+		opts.FlushLevel = slog.LevelError
+	}
+	if opts.MaxRecords <= 0 {
+		opts.MaxRecords = defaultBufferMaxRecords
+	}
+	if opts.MaxTraces <= 0 {
+		opts.MaxTraces = defaultBufferMaxTraces
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = defaultBufferTTL
+	}
+
+	return &recordBuffer{
+		opts:   opts,
+		traces: make(map[trace.TraceID]*traceBuffer),
+		order:  list.New(),
+		now:    time.Now,
+	}
+}
+
+// handle buffers the record if it belongs to a trace and is below the buffer level.
+// A record at or above the flush level returns the buffered records of its trace, which must be
+// handled before the record itself. It reports whether the record was buffered.
+func (b *recordBuffer) handle(h *Handler, ctx context.Context, record slog.Record) ([]bufferedRecord, bool) {
+	traceID := trace.SpanContextFromContext(ctx).TraceID()
+	if !traceID.IsValid() {
+		return nil, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.evictExpired()
+
+	if record.Level >= b.opts.FlushLevel.Level() {
+		tb := b.traceBuffer(traceID)
+		records := tb.records
+		tb.records, tb.flushed = nil, true
+		return records, false
+	}
+
+	if record.Level >= b.opts.Level.Level() {
+		return nil, false
+	}
+
+	tb := b.traceBuffer(traceID)
+	if tb.flushed {
+		return nil, false
+	}
+
+	if len(tb.records) >= b.opts.MaxRecords {
+		tb.records = tb.records[1:]
+	}
+	tb.records = append(tb.records, bufferedRecord{handler: h, ctx: ctx, record: record.Clone()})
+	return nil, true
+}
+
+// traceBuffer returns the buffer of the trace, creating it and evicting the oldest trace if needed.
+func (b *recordBuffer) traceBuffer(traceID trace.TraceID) *traceBuffer {
+	if tb, ok := b.traces[traceID]; ok {
+		return tb
+	}
+
+	if b.order.Len() >= b.opts.MaxTraces {
+		b.remove(b.order.Front().Value.(trace.TraceID))
+	}
+
+	tb := &traceBuffer{elem: b.order.PushBack(traceID), created: b.now()}
+	b.traces[traceID] = tb
+	return tb
+}
+
+// evictExpired discards the traces buffered for longer than the TTL.
+func (b *recordBuffer) evictExpired() {
+	deadline := b.now().Add(-b.opts.TTL)
+	for elem := b.order.Front(); elem != nil; elem = b.order.Front() {
+		traceID := elem.Value.(trace.TraceID)
+		if b.traces[traceID].created.After(deadline) {
+			return
+		}
+		b.remove(traceID)
+	}
+}
+
+// remove discards the buffered records of the trace.
+func (b *recordBuffer) remove(traceID trace.TraceID) {
+	if tb, ok := b.traces[traceID]; ok {
+		b.order.Remove(tb.elem)
+		delete(b.traces, traceID)
+	}
+}
+
+// discard discards the buffered records of the trace, e.g. when its root span ends.
+func (b *recordBuffer) discard(traceID trace.TraceID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(traceID)
+}
+
+// drain removes and returns the buffered records of all traces in the order the traces were first buffered.
+func (b *recordBuffer) drain() []bufferedRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var records []bufferedRecord
+	for elem := b.order.Front(); elem != nil; elem = elem.Next() {
+		records = append(records, b.traces[elem.Value.(trace.TraceID)].records...)
+	}
+	b.traces = make(map[trace.TraceID]*traceBuffer)
+	b.order.Init()
+	return records
+}
+
+// handleBuffered passes buffered records to the next handler of the handler they were logged with.
+func handleBuffered(records []bufferedRecord) error {
+	var errs []error
+	for _, r := range records {
+		if err := r.handler.nextHandle(r.ctx, r.record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush passes all records held by WithTraceBuffer to the next handler, regardless of their trace outcome,
+// and waits until the span events queued by WithAsyncEvents are recorded or the context is done.
+// It is intended to be called before the program exits.
+func (h *Handler) Flush(ctx context.Context) error {
+	var err error
+	if h.buffer != nil {
+		err = handleBuffered(h.buffer.drain())
+	}
+
+	if h.events != nil {
+		if flushErr := h.events.flush(ctx); flushErr != nil {
+			return flushErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestHandlerTraceBuffer(t *testing.T) {
+	otel.SetTracerProvider(trace.NewTracerProvider())
+
+	setupLogger := func(opts BufferOptions) (*bytes.Buffer, *slog.Logger, *Handler) {
+		buf := bytes.NewBuffer(nil)
+		h := NewHandler(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}), WithTraceBuffer(opts))
+		return buf, slog.New(h), h
+	}
+
+	t.Run("flush on error", func(t *testing.T) {
+		buf, logger, _ := setupLogger(BufferOptions{})
+
+		spanCtx := NewSpanContext("span", "trace")
+		logger.InfoContext(spanCtx, "info")
+		logger.DebugContext(spanCtx, "debug")
+		logger.WarnContext(spanCtx, "warn")
+		logger.Info("no trace")
+
+		assert.NotContains(t, buf.String(), `"msg":"debug"`)
+		assert.NotContains(t, buf.String(), `"msg":"info"`)
+		assert.Contains(t, buf.String(), `"msg":"warn"`)
+		assert.Contains(t, buf.String(), `"msg":"no trace"`)
+
+		logger.ErrorContext(spanCtx, "error")
+		logger.InfoContext(spanCtx, "after")
+		spanCtx.End()
+
+		out := buf.String()
+		assert.Contains(t, out, `"msg":"debug"`)
+		assert.Contains(t, out, `"msg":"after"`)
+		assert.Less(t, bytes.Index(buf.Bytes(), []byte(`"msg":"info"`)), bytes.Index(buf.Bytes(), []byte(`"msg":"error"`)))
+	})
+
+	t.Run("discard on root end", func(t *testing.T) {
+		buf, logger, h := setupLogger(BufferOptions{})
+
+		root := NewSpanContext("root", "trace")
+		logger.InfoContext(root, "root info")
+		child := NewSpanContextWithContext(root, "child", "trace")
+		logger.InfoContext(child, "child info")
+		child.End()
+
+		assert.Equal(t, 1, len(h.buffer.traces))
+
+		root.End()
+
+		assert.Equal(t, 0, len(h.buffer.traces))
+		assert.NoError(t, h.Flush(context.Background()))
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		buf, logger, h := setupLogger(BufferOptions{})
+
+		spanCtx := NewSpanContext("span", "trace")
+		logger.WithGroup("group").InfoContext(spanCtx, "grouped", "key", "value")
+
+		assert.Empty(t, buf.String())
+		assert.NoError(t, h.Flush(context.Background()))
+		assert.Contains(t, buf.String(), `"group":{"key":"value"`)
+		spanCtx.End()
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		buf, logger, h := setupLogger(BufferOptions{MaxRecords: 2, MaxTraces: 2, TTL: time.Minute})
+		now := time.Now()
+		h.buffer.now = func() time.Time { return now }
+
+		ctxs := make([]context.Context, 3)
+		for i := range ctxs {
+			ctxs[i] = oteltrace.ContextWithSpanContext(context.Background(), oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+				TraceID: oteltrace.TraceID{byte(i + 1)},
+				SpanID:  oteltrace.SpanID{1},
+			}))
+		}
+
+		for i := 0; i < 3; i++ {
+			logger.InfoContext(ctxs[0], "record", "i", i)
+		}
+		assert.Equal(t, 2, len(h.buffer.traces[oteltrace.TraceID{1}].records))
+
+		logger.InfoContext(ctxs[1], "second trace")
+		logger.InfoContext(ctxs[2], "third trace")
+		assert.Equal(t, 2, len(h.buffer.traces))
+		assert.NotContains(t, h.buffer.traces, oteltrace.TraceID{1})
+
+		now = now.Add(2 * time.Minute)
+		logger.ErrorContext(ctxs[2], "expired")
+		assert.Equal(t, 1, len(h.buffer.traces))
+		assert.NotContains(t, buf.String(), `"msg":"third trace"`)
+		assert.Contains(t, buf.String(), `"msg":"expired"`)
+	})
+}
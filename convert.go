@@ -0,0 +1,432 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxFlattenDepth limits how deep maps and structs are flattened into dotted keys.
+// Values nested deeper than this are recorded with their %+v representation.
+const maxFlattenDepth = 8
+
+// DurationFormat controls how time.Duration values are recorded as span event attributes.
+type DurationFormat int
+
+const (
+	// DurationNanoseconds records durations as an int64 number of nanoseconds.
+	DurationNanoseconds DurationFormat = iota
+	// DurationMicroseconds records durations as an int64 number of microseconds.
+	DurationMicroseconds
+	// DurationMilliseconds records durations as an int64 number of milliseconds.
+	DurationMilliseconds
+	// DurationSeconds records durations as a float64 number of seconds.
+	DurationSeconds
+	// DurationString records durations using time.Duration.String, e.g. "1.5s".
+	DurationString
+)
+
+// Converter converts a value logged with slog.Any into OpenTelemetry attributes.
+// The key is the fully qualified attribute key including any group prefix.
+// It reports false if it does not handle the value, in which case the next converter
+// or the default conversion is used.
+type Converter func(key string, value any) ([]attribute.KeyValue, bool)
+
+var (
+	convertersMu sync.RWMutex
+	converters   []Converter
+)
+
+// RegisterConverter registers a package-wide conversion for values of type T.
+// Registered converters are consulted by every Handler after the converters
+// configured with WithConverter and before the default conversion.
+// It is intended to be called during program initialization.
+func RegisterConverter[T any](fn func(key string, value T) []attribute.KeyValue) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+
+	converters = append(converters, func(key string, value any) ([]attribute.KeyValue, bool) {
+		v, ok := value.(T)
+		if !ok {
+			return nil, false
+		}
+		return fn(key, v), true
+	})
+}
+
+// resetConverters removes the converters registered with RegisterConverter.
+func resetConverters() {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+
+	converters = nil
+}
+
+// registeredConverters returns the converters registered with RegisterConverter.
+func registeredConverters() []Converter {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+
+	return converters
+}
+
+// convert applies the handler's converters followed by the registered converters to the value.
+// It reports whether one of them handled the value.
+func (h *Handler) convert(key string, value any, handler func(attribute.KeyValue)) bool {
+	for _, list := range [][]Converter{h.converters, registeredConverters()} {
+		for _, c := range list {
+			kvs, ok := c(key, value)
+			if !ok {
+				continue
+			}
+			for _, kv := range kvs {
+				handler(kv)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// convertAttrsPrefix converts slog.Attrs to OpenTelemetry attributes,
+// prefixing the attribute key with the dotted group prefix unless it is empty.
+func (h *Handler) convertAttrsPrefix(attr slog.Attr, handler func(attribute.KeyValue), prefix string) {
+	h.convertAttrsDepth(attr, handler, 0, prefix)
+}
+
+// convertAttrsDepth converts slog.Attrs to OpenTelemetry attributes,
+// tracking the nesting depth of flattened maps and structs.
+func (h *Handler) convertAttrsDepth(attr slog.Attr, handler func(attribute.KeyValue), depth int, prefix string) {
+	key := attr.Key
+	if prefix != "" {
+		key = prefix + "." + attr.Key
+	}
+
+	val := attr.Value.Resolve()
+
+	switch val.Kind() {
+	case slog.KindBool:
+		handler(attribute.Bool(key, val.Bool()))
+	case slog.KindDuration:
+		handler(h.convertDuration(key, val.Duration()))
+	case slog.KindFloat64:
+		handler(attribute.Float64(key, val.Float64()))
+	case slog.KindInt64:
+		handler(attribute.Int64(key, val.Int64()))
+	case slog.KindString:
+		handler(attribute.String(key, val.String()))
+	case slog.KindTime:
+		handler(attribute.String(key, val.Time().Format(time.RFC3339Nano)))
+	case slog.KindUint64:
+		handler(convertUint64(key, val.Uint64()))
+	case slog.KindGroup:
+		for _, groupAttr := range val.Group() {
+			h.convertAttrsDepth(groupAttr, handler, depth, key)
+		}
+	case slog.KindAny:
+		h.convertAnyValue(key, val.Any(), handler, depth)
+	default:
+		h.countConversionFallback()
+		handler(attribute.String(key, fmt.Sprintf("%+v", val.Any())))
+	}
+}
+
+// convertDuration converts a time.Duration according to the handler's duration format.
+func (h *Handler) convertDuration(key string, d time.Duration) attribute.KeyValue {
+	switch h.durationFormat {
+	case DurationMicroseconds:
+		return attribute.Int64(key, d.Microseconds())
+	case DurationMilliseconds:
+		return attribute.Int64(key, d.Milliseconds())
+	case DurationSeconds:
+		return attribute.Float64(key, d.Seconds())
+	case DurationString:
+		return attribute.String(key, d.String())
+	default:
+		return attribute.Int64(key, int64(d))
+	}
+}
+
+// convertUint64 converts a uint64 to an int64 attribute.
+// Values that overflow int64 are recorded as their decimal string representation.
+func convertUint64(key string, v uint64) attribute.KeyValue {
+	if v > math.MaxInt64 {
+		return attribute.String(key, strconv.FormatUint(v, 10))
+	}
+	return attribute.Int64(key, int64(v))
+}
+
+// convertAnyValue converts slog.Any to OpenTelemetry attributes.
+// It handles different types of values and passes the appropriate attribute.KeyValue to the handler.
+// Maps and structs are flattened into dotted keys.
+// Custom converters take precedence over the built-in conversions.
+func (h *Handler) convertAnyValue(key string, value any, handler func(attribute.KeyValue), depth int) {
+	if value != nil && h.convert(key, value, handler) {
+		return
+	}
+	// Methods such as Error and String may dereference their receiver, so they are not called on nil pointers.
+	if isNilPointer(value) {
+		handler(attribute.String(key, "<nil>"))
+		return
+	}
+
+	switch v := value.(type) {
+	case nil:
+		handler(attribute.String(key, fmt.Sprintf("%+v", v)))
+	case attribute.Value:
+		if v.Type() == attribute.INVALID {
+			h.recordDroppedAttribute(dropReasonInvalid)
+			return
+		}
+		handler(attribute.KeyValue{Key: attribute.Key(key), Value: v})
+	case []string:
+		handler(attribute.StringSlice(key, v))
+	case []int:
+		handler(attribute.IntSlice(key, v))
+	case []int64:
+		handler(attribute.Int64Slice(key, v))
+	case []float64:
+		handler(attribute.Float64Slice(key, v))
+	case []bool:
+		handler(attribute.BoolSlice(key, v))
+	case []byte:
+		handler(attribute.String(key, string(v)))
+	case []any:
+		handler(h.convertAnySlice(key, v))
+	case error:
+		handler(attribute.String(key, v.Error()))
+	case fmt.Stringer:
+		handler(attribute.String(key, v.String()))
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		if err != nil {
+			h.reportConversionFallback(key, err)
+			handler(attribute.String(key, fmt.Sprintf("%+v", v)))
+			return
+		}
+		handler(attribute.String(key, string(text)))
+	case json.Marshaler:
+		data, err := v.MarshalJSON()
+		if err != nil {
+			h.reportConversionFallback(key, err)
+			handler(attribute.String(key, fmt.Sprintf("%+v", v)))
+			return
+		}
+		handler(attribute.String(key, string(data)))
+	default:
+		h.convertReflectValue(key, reflect.ValueOf(v), handler, depth)
+	}
+}
+
+// convertReflectValue converts slices, maps and structs that have no dedicated conversion.
+// Slices of basic types become typed slice attributes, maps and structs are flattened into dotted keys,
+// and anything else, including maps and structs that flatten to no attributes, is recorded with its %+v
+// representation.
+func (h *Handler) convertReflectValue(key string, rv reflect.Value, handler func(attribute.KeyValue), depth int) {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			handler(attribute.String(key, "<nil>"))
+			return
+		}
+		rv = rv.Elem()
+	}
+
+	if depth >= maxFlattenDepth {
+		h.recordDroppedAttribute(dropReasonTruncated)
+		handler(attribute.String(key, fmt.Sprintf("%+v", rv.Interface())))
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		handler(h.convertReflectSlice(key, rv))
+	case reflect.Map, reflect.Struct:
+		flattened := false
+		h.flattenReflectValue(key, rv, func(kv attribute.KeyValue) {
+			flattened = true
+			handler(kv)
+		}, depth)
+		if !flattened {
+			h.countConversionFallback()
+			handler(attribute.String(key, fmt.Sprintf("%+v", rv.Interface())))
+		}
+	default:
+		h.countConversionFallback()
+		handler(attribute.String(key, fmt.Sprintf("%+v", rv.Interface())))
+	}
+}
+
+// flattenReflectValue flattens a map or struct into dotted keys.
+// Map entries are sorted by key, and struct fields are named by fieldName.
+func (h *Handler) flattenReflectValue(key string, rv reflect.Value, handler func(attribute.KeyValue), depth int) {
+	switch rv.Kind() {
+	case reflect.Map:
+		entries := make([]slog.Attr, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			entries = append(entries, slog.Any(fmt.Sprint(iter.Key().Interface()), iter.Value().Interface()))
+		}
+		slices.SortFunc(entries, func(a, b slog.Attr) int { return strings.Compare(a.Key, b.Key) })
+		for _, entry := range entries {
+			h.convertAttrsDepth(entry, handler, depth+1, key)
+		}
+	case reflect.Struct:
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			name, ok := fieldName(field)
+			if !ok {
+				continue
+			}
+			h.convertAttrsDepth(slog.Any(name, rv.Field(i).Interface()), handler, depth+1, key)
+		}
+	}
+}
+
+// isNilPointer reports whether the value is a nil pointer.
+func isNilPointer(value any) bool {
+	rv := reflect.ValueOf(value)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// fieldName returns the attribute name of an exported struct field.
+// It honors the name in the field's json tag and reports false for unexported or ignored fields.
+func fieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, true
+	}
+
+	return field.Name, true
+}
+
+// convertAnySlice converts a []any to a typed slice attribute when all elements share a basic type.
+// Otherwise, each element is recorded with its %+v representation.
+func (h *Handler) convertAnySlice(key string, values []any) attribute.KeyValue {
+	return h.convertReflectSlice(key, reflect.ValueOf(values))
+}
+
+// convertReflectSlice converts a slice or array to a typed slice attribute when all elements share a basic type.
+// Otherwise, each element is recorded with its %+v representation.
+func (h *Handler) convertReflectSlice(key string, rv reflect.Value) attribute.KeyValue {
+	n := rv.Len()
+	elems := make([]reflect.Value, n)
+	kind := reflect.Invalid
+	for i := 0; i < n; i++ {
+		elems[i] = rv.Index(i)
+		for elems[i].Kind() == reflect.Interface && !elems[i].IsNil() {
+			elems[i] = elems[i].Elem()
+		}
+
+		k := basicKind(elems[i])
+		if i == 0 {
+			kind = k
+		} else if k != kind {
+			kind = reflect.Invalid
+		}
+	}
+
+	switch kind {
+	case reflect.Bool:
+		out := make([]bool, n)
+		for i, e := range elems {
+			out[i] = e.Bool()
+		}
+		return attribute.BoolSlice(key, out)
+	case reflect.Int64:
+		out := make([]int64, n)
+		for i, e := range elems {
+			if e.CanInt() {
+				out[i] = e.Int()
+				continue
+			}
+			if e.Uint() > math.MaxInt64 {
+				return h.convertStringSlice(key, elems)
+			}
+			out[i] = int64(e.Uint())
+		}
+		return attribute.Int64Slice(key, out)
+	case reflect.Float64:
+		out := make([]float64, n)
+		for i, e := range elems {
+			out[i] = e.Float()
+		}
+		return attribute.Float64Slice(key, out)
+	case reflect.String:
+		out := make([]string, n)
+		for i, e := range elems {
+			out[i] = e.String()
+		}
+		return attribute.StringSlice(key, out)
+	default:
+		return h.convertStringSlice(key, elems)
+	}
+}
+
+// convertStringSlice records each element with its %+v representation.
+// It counts a conversion fallback if an element is neither of a basic type nor a duration.
+func (h *Handler) convertStringSlice(key string, elems []reflect.Value) attribute.KeyValue {
+	out := make([]string, len(elems))
+	fallback := false
+	for i, e := range elems {
+		if !e.IsValid() || !e.CanInterface() {
+			out[i] = "<nil>"
+			continue
+		}
+		if basicKind(e) == reflect.Invalid && e.Type() != durationType {
+			fallback = true
+		}
+		out[i] = fmt.Sprintf("%+v", e.Interface())
+	}
+	if fallback {
+		h.countConversionFallback()
+	}
+	return attribute.StringSlice(key, out)
+}
+
+// durationType is the type of time.Duration, whose slice elements are recorded using their string form.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// basicKind groups the kind of a slice element into the basic kinds supported by attribute slices.
+// Durations are reported as reflect.Invalid so that they are recorded using their string form.
+func basicKind(v reflect.Value) reflect.Kind {
+	if !v.IsValid() || v.Type() == durationType {
+		return reflect.Invalid
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return reflect.Bool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Int64
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	case reflect.String:
+		return reflect.String
+	default:
+		return reflect.Invalid
+	}
+}
@@ -0,0 +1,420 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestConvertAttrs(t *testing.T) {
+	tests := []struct {
+		name     string
+		attr     slog.Attr
+		expected attribute.KeyValue
+	}{
+		{
+			name:     "string",
+			attr:     slog.String("key1", "value1"),
+			expected: attribute.String("log.key1", "value1"),
+		},
+		{
+			name:     "int64",
+			attr:     slog.Int64("key2", 42),
+			expected: attribute.Int64("log.key2", 42),
+		},
+		{
+			name:     "bool",
+			attr:     slog.Bool("key3", true),
+			expected: attribute.Bool("log.key3", true),
+		},
+		{
+			name:     "duration",
+			attr:     slog.Duration("key4", 5*time.Second),
+			expected: attribute.Int64("log.key4", 5000000000), // 5 seconds in nanoseconds
+		},
+		{
+			name:     "float64",
+			attr:     slog.Float64("key5", 3.14),
+			expected: attribute.Float64("log.key5", 3.14),
+		},
+		{
+			name:     "time",
+			attr:     slog.Time("key6", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)),
+			expected: attribute.String("log.key6", "2023-01-01T00:00:00Z"),
+		},
+		{
+			name:     "any",
+			attr:     slog.Any("key7", []string{"value1", "value2"}),
+			expected: attribute.StringSlice("log.key7", []string{"value1", "value2"}),
+		},
+		{
+			name:     "uint64",
+			attr:     slog.Uint64("key8", 100),
+			expected: attribute.Int64("log.key8", 100),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := make([]attribute.KeyValue, 0)
+			NewHandler(nil).convertAttrsPrefix(test.attr, func(kv attribute.KeyValue) {
+				result = append(result, kv)
+			}, "log")
+			assert.Equal(t, test.expected, result[0])
+		})
+	}
+}
+
+func TestConvertAttrsWithGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		attr     slog.Attr
+		expected []attribute.KeyValue
+	}{
+		{
+			name:     "string and int64",
+			attr:     slog.Group("group", slog.String("key1", "value1"), slog.Int64("key2", 42)),
+			expected: []attribute.KeyValue{attribute.String("log.group.key1", "value1"), attribute.Int64("log.group.key2", 42)},
+		},
+		{
+			name:     "int64",
+			attr:     slog.Group("group", slog.Int64("key2", 42)),
+			expected: []attribute.KeyValue{attribute.Int64("log.group.key2", 42)},
+		},
+		{
+			name:     "bool",
+			attr:     slog.Group("group", slog.Bool("key3", true)),
+			expected: []attribute.KeyValue{attribute.Bool("log.group.key3", true)},
+		},
+		{
+			name:     "duration",
+			attr:     slog.Group("group", slog.Duration("key4", 5*time.Second)),
+			expected: []attribute.KeyValue{attribute.Int64("log.group.key4", 5000000000)}, // 5 seconds in nanoseconds
+		},
+		{
+			name:     "float64",
+			attr:     slog.Group("group", slog.Float64("key5", 3.14)),
+			expected: []attribute.KeyValue{attribute.Float64("log.group.key5", 3.14)},
+		},
+		{
+			name:     "time",
+			attr:     slog.Group("group", slog.Time("key6", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))),
+			expected: []attribute.KeyValue{attribute.String("log.group.key6", "2023-01-01T00:00:00Z")},
+		},
+		{
+			name:     "nested string",
+			attr:     slog.Group("group", slog.Group("subgroup", slog.String("key7", "value2"))),
+			expected: []attribute.KeyValue{attribute.String("log.group.subgroup.key7", "value2")},
+		},
+		{
+			name:     "nested int64",
+			attr:     slog.Group("group", slog.Group("subgroup", slog.Int64("key8", 100))),
+			expected: []attribute.KeyValue{attribute.Int64("log.group.subgroup.key8", 100)},
+		},
+		{
+			name:     "nested bool",
+			attr:     slog.Group("group", slog.Group("subgroup", slog.Bool("key9", false))),
+			expected: []attribute.KeyValue{attribute.Bool("log.group.subgroup.key9", false)},
+		},
+		{
+			name:     "nested duration",
+			attr:     slog.Group("group", slog.Group("subgroup", slog.Duration("key10", 10*time.Second))),
+			expected: []attribute.KeyValue{attribute.Int64("log.group.subgroup.key10", 10000000000)},
+		},
+		{
+			name:     "nested float64",
+			attr:     slog.Group("group", slog.Group("subgroup", slog.Float64("key11", 6.28))),
+			expected: []attribute.KeyValue{attribute.Float64("log.group.subgroup.key11", 6.28)},
+		},
+		{
+			name:     "nested time",
+			attr:     slog.Group("group", slog.Group("subgroup", slog.Time("key12", time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)))),
+			expected: []attribute.KeyValue{attribute.String("log.group.subgroup.key12", "2023-02-01T00:00:00Z")},
+		},
+		{
+			name: "any",
+			attr: slog.Group("group", slog.Any("key13",
+				[]string{"value1", "value2"}), slog.Any("key14",
+				[]int{1, 2}), slog.Any("key15", []int64{3, 4}),
+				slog.Any("key16", []float64{5.0, 6.0}),
+				slog.Any("key17", []bool{true, false}),
+				slog.Any("key18", struct {
+					Field1 string
+					Field2 int
+				}{
+					Field1: "value1",
+					Field2: 2,
+				})),
+			expected: []attribute.KeyValue{
+				attribute.StringSlice("log.group.key13", []string{"value1", "value2"}),
+				attribute.IntSlice("log.group.key14", []int{1, 2}),
+				attribute.Int64Slice("log.group.key15", []int64{3, 4}),
+				attribute.Float64Slice("log.group.key16", []float64{5.0, 6.0}),
+				attribute.BoolSlice("log.group.key17", []bool{true, false}),
+				attribute.String("log.group.key18.Field1", "value1"),
+				attribute.Int64("log.group.key18.Field2", 2),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := make([]attribute.KeyValue, 0)
+			NewHandler(nil).convertAttrsPrefix(test.attr, func(kv attribute.KeyValue) {
+				result = append(result, kv)
+			}, "log")
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+type testStringer struct{}
+
+func (s testStringer) String() string { return "stringer" }
+
+type testTextMarshaler struct{}
+
+func (testTextMarshaler) MarshalText() ([]byte, error) { return []byte("text"), nil }
+
+type testJSONMarshaler struct{}
+
+func (testJSONMarshaler) MarshalJSON() ([]byte, error) { return []byte(`{"json":true}`), nil }
+
+type testError struct{ msg string }
+
+func (e *testError) Error() string { return e.msg }
+
+func TestConvertAnyValue(t *testing.T) {
+	type nested struct {
+		Name  string `json:"name"`
+		Skip  string `json:"-"`
+		Inner struct {
+			Count uint8
+		} `json:"inner,omitempty"`
+		hidden int
+	}
+
+	tests := []struct {
+		name     string
+		attr     slog.Attr
+		expected []attribute.KeyValue
+	}{
+		{
+			name:     "uint64 overflow",
+			attr:     slog.Uint64("key", math.MaxUint64),
+			expected: []attribute.KeyValue{attribute.String("log.key", "18446744073709551615")},
+		},
+		{
+			name:     "time nanoseconds",
+			attr:     slog.Time("key", time.Date(2023, 1, 1, 0, 0, 0, 123456789, time.UTC)),
+			expected: []attribute.KeyValue{attribute.String("log.key", "2023-01-01T00:00:00.123456789Z")},
+		},
+		{
+			name:     "error",
+			attr:     slog.Any("key", errors.New("boom")),
+			expected: []attribute.KeyValue{attribute.String("log.key", "boom")},
+		},
+		{
+			name:     "stringer",
+			attr:     slog.Any("key", testStringer{}),
+			expected: []attribute.KeyValue{attribute.String("log.key", "stringer")},
+		},
+		{
+			name:     "text marshaler",
+			attr:     slog.Any("key", testTextMarshaler{}),
+			expected: []attribute.KeyValue{attribute.String("log.key", "text")},
+		},
+		{
+			name:     "json marshaler",
+			attr:     slog.Any("key", testJSONMarshaler{}),
+			expected: []attribute.KeyValue{attribute.String("log.key", `{"json":true}`)},
+		},
+		{
+			name:     "net.IP",
+			attr:     slog.Any("key", net.IPv4(127, 0, 0, 1)),
+			expected: []attribute.KeyValue{attribute.String("log.key", "127.0.0.1")},
+		},
+		{
+			name:     "attribute.Value",
+			attr:     slog.Any("key", attribute.Int64SliceValue([]int64{1, 2})),
+			expected: []attribute.KeyValue{attribute.Int64Slice("log.key", []int64{1, 2})},
+		},
+		{
+			name:     "any slice of ints",
+			attr:     slog.Any("key", []any{1, int32(2), uint8(3)}),
+			expected: []attribute.KeyValue{attribute.Int64Slice("log.key", []int64{1, 2, 3})},
+		},
+		{
+			name:     "any slice of mixed values",
+			attr:     slog.Any("key", []any{"a", 1, true}),
+			expected: []attribute.KeyValue{attribute.StringSlice("log.key", []string{"a", "1", "true"})},
+		},
+		{
+			name:     "typed slice",
+			attr:     slog.Any("key", []float32{1.5, 2.5}),
+			expected: []attribute.KeyValue{attribute.Float64Slice("log.key", []float64{1.5, 2.5})},
+		},
+		{
+			name: "map",
+			attr: slog.Any("key", map[string]any{"b": 2, "a": "x", "c": map[string]bool{"d": true}}),
+			expected: []attribute.KeyValue{
+				attribute.String("log.key.a", "x"),
+				attribute.Int64("log.key.b", 2),
+				attribute.Bool("log.key.c.d", true),
+			},
+		},
+		{
+			name: "nested struct pointer",
+			attr: slog.Any("key", &nested{Name: "n", Skip: "s", Inner: struct{ Count uint8 }{Count: 3}, hidden: 1}),
+			expected: []attribute.KeyValue{
+				attribute.String("log.key.name", "n"),
+				attribute.Int64("log.key.inner.Count", 3),
+			},
+		},
+		{
+			name:     "nil pointer",
+			attr:     slog.Any("key", (*nested)(nil)),
+			expected: []attribute.KeyValue{attribute.String("log.key", "<nil>")},
+		},
+		{
+			name:     "nil error pointer",
+			attr:     slog.Any("key", (*testError)(nil)),
+			expected: []attribute.KeyValue{attribute.String("log.key", "<nil>")},
+		},
+		{
+			name:     "nil stringer pointer",
+			attr:     slog.Any("key", (*testStringer)(nil)),
+			expected: []attribute.KeyValue{attribute.String("log.key", "<nil>")},
+		},
+		{
+			name:     "nil text marshaler pointer",
+			attr:     slog.Any("key", (*testTextMarshaler)(nil)),
+			expected: []attribute.KeyValue{attribute.String("log.key", "<nil>")},
+		},
+		{
+			name:     "nil json marshaler pointer",
+			attr:     slog.Any("key", (*testJSONMarshaler)(nil)),
+			expected: []attribute.KeyValue{attribute.String("log.key", "<nil>")},
+		},
+		{
+			name:     "struct without exported fields",
+			attr:     slog.Any("key", struct{ a int }{a: 1}),
+			expected: []attribute.KeyValue{attribute.String("log.key", "{a:1}")},
+		},
+		{
+			name:     "empty map",
+			attr:     slog.Any("key", map[string]int{}),
+			expected: []attribute.KeyValue{attribute.String("log.key", "map[]")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := make([]attribute.KeyValue, 0)
+			NewHandler(nil).convertAttrsPrefix(test.attr, func(kv attribute.KeyValue) {
+				result = append(result, kv)
+			}, "log")
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+func TestConvertDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   DurationFormat
+		expected attribute.KeyValue
+	}{
+		{name: "nanoseconds", format: DurationNanoseconds, expected: attribute.Int64("key", 1500000000)},
+		{name: "microseconds", format: DurationMicroseconds, expected: attribute.Int64("key", 1500000)},
+		{name: "milliseconds", format: DurationMilliseconds, expected: attribute.Int64("key", 1500)},
+		{name: "seconds", format: DurationSeconds, expected: attribute.Float64("key", 1.5)},
+		{name: "string", format: DurationString, expected: attribute.String("key", "1.5s")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := make([]attribute.KeyValue, 0)
+			NewHandler(nil, WithDurationFormat(test.format)).convertAttrsPrefix(slog.Duration("key", 1500*time.Millisecond), func(kv attribute.KeyValue) {
+				result = append(result, kv)
+			}, "")
+			assert.Equal(t, []attribute.KeyValue{test.expected}, result)
+		})
+	}
+}
+
+type testMoney struct {
+	Amount   int64
+	Currency string
+}
+
+type testGeoPoint struct {
+	Lat, Lon float64
+}
+
+func TestConverters(t *testing.T) {
+	t.Cleanup(resetConverters)
+
+	RegisterConverter(func(key string, v testGeoPoint) []attribute.KeyValue {
+		return []attribute.KeyValue{attribute.Float64Slice(key, []float64{v.Lat, v.Lon})}
+	})
+
+	h := NewHandler(nil, WithConverter(func(key string, value any) ([]attribute.KeyValue, bool) {
+		m, ok := value.(testMoney)
+		if !ok {
+			return nil, false
+		}
+		return []attribute.KeyValue{attribute.String(key, fmt.Sprintf("%d %s", m.Amount, m.Currency))}, true
+	}))
+
+	tests := []struct {
+		name     string
+		attr     slog.Attr
+		expected []attribute.KeyValue
+	}{
+		{
+			name:     "handler converter",
+			attr:     slog.Any("price", testMoney{Amount: 100, Currency: "USD"}),
+			expected: []attribute.KeyValue{attribute.String("log.price", "100 USD")},
+		},
+		{
+			name:     "registered converter",
+			attr:     slog.Any("location", testGeoPoint{Lat: 1.5, Lon: 2.5}),
+			expected: []attribute.KeyValue{attribute.Float64Slice("log.location", []float64{1.5, 2.5})},
+		},
+		{
+			name: "nested value",
+			attr: slog.Any("order", struct{ Price testMoney }{Price: testMoney{Amount: 5, Currency: "EUR"}}),
+			expected: []attribute.KeyValue{
+				attribute.String("log.order.Price", "5 EUR"),
+			},
+		},
+		{
+			name:     "default conversion",
+			attr:     slog.Any("tags", []string{"a"}),
+			expected: []attribute.KeyValue{attribute.StringSlice("log.tags", []string{"a"})},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := make([]attribute.KeyValue, 0)
+			h.convertAttrsPrefix(test.attr, func(kv attribute.KeyValue) {
+				result = append(result, kv)
+			}, "log")
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
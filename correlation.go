@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// noopProviderMessage is the message of the warning logged when spans are started with a noop TracerProvider.
+const noopProviderMessage = "otelslog: the global TracerProvider does not record spans, so trace IDs are not logged; " +
+	"install one with otel.SetTracerProvider or use WithLocalTraceIDs"
+
+// IDGenerator generates W3C trace context compatible trace and span IDs for SpanContexts whose spans are
+// not recorded. It has the same methods as the IDGenerator of the OpenTelemetry SDK, so SDK generators can be reused.
+type IDGenerator interface {
+	// NewIDs returns a new trace ID and span ID for a span without a parent trace.
+	NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID)
+
+	// NewSpanID returns a new span ID for a span of the given trace.
+	NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID
+}
+
+// randomIDGenerator generates random trace and span IDs.
+type randomIDGenerator struct{}
+
+// NewIDs returns a random trace ID and span ID.
+func (randomIDGenerator) NewIDs(context.Context) (trace.TraceID, trace.SpanID) {
+	return newTraceID(), newSpanID()
+}
+
+// NewSpanID returns a random span ID.
+func (randomIDGenerator) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	return newSpanID()
+}
+
+// WithLocalTraceIDs writes the trace and span IDs of SpanContexts whose spans are not recorded, so that
+// related records can still be correlated in CLI tools and tests that run without a tracer.
+// Spans started with a noop TracerProvider, e.g. because none is installed, get random local IDs that
+// continue the trace of their parent and are propagated to child SpanContexts; nothing is exported.
+// Without this option, the handler logs a one-time warning through the next handler instead.
+func WithLocalTraceIDs() Options {
+	return WithIDGenerator(randomIDGenerator{})
+}
+
+// WithIDGenerator is like WithLocalTraceIDs, but generates the local IDs with the generator.
+// A nil generator generates random IDs.
+func WithIDGenerator(generator IDGenerator) Options {
+	return func(h *Handler) {
+		if generator == nil {
+			generator = randomIDGenerator{}
+		}
+		h.idGenerator = generator
+	}
+}
+
+// isNoopSpan reports whether the span was started by a noop TracerProvider.
+// A noop span either has no span context or carries the span context of its parent.
+func isNoopSpan(span trace.Span, parent trace.SpanContext) bool {
+	spanCtx := span.SpanContext()
+	return !spanCtx.IsValid() || spanCtx.SpanID() == parent.SpanID()
+}
+
+// handleNonRecordingSpan handles a span that is not recorded. If local IDs are enabled, the span state is marked
+// so that its IDs are written, and a span started by a noop TracerProvider gets IDs from the ID generator,
+// continuing the trace of its parent. Otherwise, a span started by a noop TracerProvider logs a one-time warning.
+func (h *Handler) handleNonRecordingSpan(span *spanState, ctx context.Context) {
+	noopSpan := isNoopSpan(span.span, span.parent)
+	if h.idGenerator == nil {
+		if noopSpan {
+			h.warnNoopProvider()
+		}
+		return
+	}
+
+	span.local = true
+	if !noopSpan {
+		return
+	}
+
+	var spanCtx trace.SpanContext
+	if span.parent.HasTraceID() {
+		spanCtx = span.parent.WithSpanID(h.idGenerator.NewSpanID(ctx, span.parent.TraceID())).WithRemote(false)
+	} else {
+		traceID, spanID := h.idGenerator.NewIDs(ctx)
+		spanCtx = trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+	}
+	span.Context = trace.ContextWithSpanContext(ctx, spanCtx)
+	span.span = trace.SpanFromContext(span.Context)
+}
+
+// warnNoopProvider logs a warning through the next handler the first time a span is started with a noop
+// TracerProvider. The warning is logged once for all handlers derived from the same Handler.
+func (h *Handler) warnNoopProvider() {
+	if h.Next == nil || h.diagnostics == nil || !h.diagnostics.noopWarned.CompareAndSwap(false, true) {
+		return
+	}
+
+	ctx := context.Background()
+	if !h.Next.Enabled(ctx, slog.LevelWarn) {
+		return
+	}
+	if err := h.Next.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelWarn, noopProviderMessage, 0)); err != nil {
+		h.reportNextError(err)
+	}
+}
+
+// newTraceID returns a random, valid trace ID.
+func newTraceID() trace.TraceID {
+	var traceID trace.TraceID
+	for !traceID.IsValid() {
+		binary.BigEndian.PutUint64(traceID[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(traceID[8:], rand.Uint64())
+	}
+	return traceID
+}
+
+// newSpanID returns a random, valid span ID.
+func newSpanID() trace.SpanID {
+	var spanID trace.SpanID
+	for !spanID.IsValid() {
+		binary.BigEndian.PutUint64(spanID[:], rand.Uint64())
+	}
+	return spanID
+}
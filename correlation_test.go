@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// sequentialIDGenerator generates sequential trace and span IDs.
+type sequentialIDGenerator struct {
+	traces, spans uint64
+}
+
+func (g *sequentialIDGenerator) NewIDs(ctx context.Context) (oteltrace.TraceID, oteltrace.SpanID) {
+	g.traces++
+	var traceID oteltrace.TraceID
+	binary.BigEndian.PutUint64(traceID[8:], g.traces)
+	return traceID, g.NewSpanID(ctx, traceID)
+}
+
+func (g *sequentialIDGenerator) NewSpanID(context.Context, oteltrace.TraceID) oteltrace.SpanID {
+	g.spans++
+	var spanID oteltrace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], g.spans)
+	return spanID
+}
+
+// decodeLines decodes the JSON records written to the buffer.
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		record := make(map[string]any)
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestNoopTracerProvider(t *testing.T) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+	defer otel.SetTracerProvider(trace.NewTracerProvider())
+
+	t.Run("warns once", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		logger := slog.New(NewHandler(slog.NewJSONHandler(buf, nil)))
+
+		span1 := NewSpanContext("span1")
+		logger.InfoContext(span1, "first")
+		span1.End()
+
+		span2 := NewSpanContext("span2")
+		logger.WithGroup("group").InfoContext(span2, "second")
+		span2.End()
+
+		records := decodeLines(t, buf)
+		if assert.Len(t, records, 3) {
+			assert.Equal(t, "WARN", records[0]["level"])
+			assert.Equal(t, noopProviderMessage, records[0]["msg"])
+			assert.Equal(t, "first", records[1]["msg"])
+			assert.NotContains(t, records[1], "trace_id")
+			assert.Equal(t, "second", records[2]["msg"])
+		}
+	})
+
+	t.Run("local trace IDs", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		logger := slog.New(NewHandler(slog.NewJSONHandler(buf, nil), WithLocalTraceIDs()))
+
+		parent := NewSpanContext("parent")
+		logger.InfoContext(parent, "parent")
+		logger.InfoContext(parent, "parent again")
+
+		child := NewSpanContextWithContext(parent, "child")
+		logger.InfoContext(child, "child")
+		child.End()
+		parent.End()
+
+		other := NewSpanContext("other")
+		logger.InfoContext(other, "other")
+		other.End()
+
+		records := decodeLines(t, buf)
+		if !assert.Len(t, records, 4) {
+			return
+		}
+
+		for _, record := range records {
+			assert.Len(t, record["trace_id"], 32)
+			assert.Len(t, record["span_id"], 16)
+		}
+		assert.Equal(t, records[0]["trace_id"], records[1]["trace_id"])
+		assert.Equal(t, records[0]["span_id"], records[1]["span_id"])
+		assert.Equal(t, records[0]["trace_id"], records[2]["trace_id"])
+		assert.NotEqual(t, records[0]["span_id"], records[2]["span_id"])
+		assert.NotEqual(t, records[0]["trace_id"], records[3]["trace_id"])
+	})
+
+	t.Run("id generator", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		logger := slog.New(NewHandler(slog.NewJSONHandler(buf, nil), WithIDGenerator(&sequentialIDGenerator{})))
+
+		parent := NewSpanContext("parent")
+		logger.InfoContext(parent, "parent")
+
+		child := NewSpanContextWithContext(parent, "child")
+		logger.InfoContext(child, "child")
+
+		grandchild := NewSpanContextWithContext(context.WithValue(child, struct{}{}, "value"), "grandchild")
+		logger.InfoContext(grandchild, "grandchild")
+		grandchild.End()
+		child.End()
+		parent.End()
+
+		records := decodeLines(t, buf)
+		if assert.Len(t, records, 3) {
+			for i, record := range records {
+				assert.Equal(t, "00000000000000000000000000000001", record["trace_id"])
+				assert.Equal(t, fmt.Sprintf("%016x", i+1), record["span_id"])
+			}
+		}
+	})
+}
+
+func TestLocalTraceIDsUnsampled(t *testing.T) {
+	otel.SetTracerProvider(trace.NewTracerProvider(trace.WithSampler(trace.NeverSample())))
+	defer otel.SetTracerProvider(trace.NewTracerProvider())
+
+	buf := bytes.NewBuffer(nil)
+	logger := slog.New(NewHandler(slog.NewJSONHandler(buf, nil), WithLocalTraceIDs()))
+
+	span := NewSpanContext("span")
+	logger.InfoContext(span, "unsampled")
+	span.End()
+
+	records := decodeLines(t, buf)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, span.SpanContext().TraceID().String(), records[0]["trace_id"])
+		assert.Equal(t, span.SpanContext().SpanID().String(), records[0]["span_id"])
+	}
+}
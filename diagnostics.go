@@ -0,0 +1,149 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+)
+
+// Stats holds counters of the problems a Handler worked around while handling records.
+// The counters are shared by all handlers derived from the same Handler.
+type Stats struct {
+	// ConversionFallbacks is the number of values recorded with their %+v representation
+	// because they have no dedicated conversion, they flatten to no attributes, or their MarshalText or
+	// MarshalJSON method failed. Slices count once if any of their elements falls back.
+	// Values truncated at the maximum flattening depth are counted in DroppedAttributes instead.
+	ConversionFallbacks int64
+
+	// DroppedAttributes is the number of span event attributes dropped or truncated during conversion.
+	DroppedAttributes int64
+
+	// NilNext is the number of records that could not be passed on because the handler has no next handler.
+	NilNext int64
+
+	// NextErrors is the number of records the next handler failed to handle.
+	NextErrors int64
+
+	// NonRecordingSpans is the number of spans started by a SpanContext that are not recording,
+	// e.g. because no TracerProvider is installed or the span was not sampled.
+	NonRecordingSpans int64
+
+	// SuppressedEvents is the number of log events not recorded on spans because of an event budget.
+	SuppressedEvents int64
+
+	// DroppedEvents is the number of span events dropped because the queue of WithAsyncEvents was full.
+	DroppedEvents int64
+}
+
+// diagnostics counts the problems of a handler and reports errors to its error handler.
+type diagnostics struct {
+	errorHandler otel.ErrorHandler
+
+	conversionFallbacks atomic.Int64
+	droppedAttributes   atomic.Int64
+	nilNext             atomic.Int64
+	nextErrors          atomic.Int64
+	nonRecordingSpans   atomic.Int64
+	suppressedEvents    atomic.Int64
+	droppedEvents       atomic.Int64
+
+	noopWarned atomic.Bool
+}
+
+// WithErrorHandler reports the errors the handler works around, such as failed value conversions and
+// errors returned by the next handler, to the error handler. slog.Logger ignores the errors returned by Handle,
+// so without an error handler they are only counted in Stats.
+// Pass otel.GetErrorHandler() to route them to the global OpenTelemetry error handler, as long as that
+// handler does not log through this Handler, e.g. via log.Print after slog.SetDefault.
+func WithErrorHandler(handler otel.ErrorHandler) Options {
+	return func(h *Handler) {
+		h.diagnostics.errorHandler = handler
+	}
+}
+
+// Stats returns the current diagnostic counters of the handler.
+func (h *Handler) Stats() Stats {
+	d := h.diagnostics
+	if d == nil {
+		return Stats{}
+	}
+
+	return Stats{
+		ConversionFallbacks: d.conversionFallbacks.Load(),
+		DroppedAttributes:   d.droppedAttributes.Load(),
+		NilNext:             d.nilNext.Load(),
+		NextErrors:          d.nextErrors.Load(),
+		NonRecordingSpans:   d.nonRecordingSpans.Load(),
+		SuppressedEvents:    d.suppressedEvents.Load(),
+		DroppedEvents:       d.droppedEvents.Load(),
+	}
+}
+
+// handleError reports an error to the handler's error handler, if set.
+func (h *Handler) handleError(err error) {
+	if h.diagnostics != nil && h.diagnostics.errorHandler != nil {
+		h.diagnostics.errorHandler.Handle(err)
+	}
+}
+
+// reportConversionFallback counts and reports a value whose conversion failed.
+func (h *Handler) reportConversionFallback(key string, err error) {
+	h.countConversionFallback()
+	h.handleError(fmt.Errorf("otelslog: convert attribute %q: %w", key, err))
+}
+
+// countConversionFallback counts a value recorded with its %+v representation.
+func (h *Handler) countConversionFallback() {
+	if h.diagnostics != nil {
+		h.diagnostics.conversionFallbacks.Add(1)
+	}
+}
+
+// reportNextError counts and reports an error of the next handler.
+func (h *Handler) reportNextError(err error) {
+	if h.diagnostics != nil {
+		h.diagnostics.nextErrors.Add(1)
+	}
+	h.handleError(fmt.Errorf("otelslog: next handler: %w", err))
+}
+
+// countNilNext counts a record that could not be passed on because the handler has no next handler.
+func (h *Handler) countNilNext() {
+	if h.diagnostics != nil {
+		h.diagnostics.nilNext.Add(1)
+	}
+}
+
+// countDroppedAttribute counts an attribute dropped or truncated during conversion.
+func (h *Handler) countDroppedAttribute() {
+	if h.diagnostics != nil {
+		h.diagnostics.droppedAttributes.Add(1)
+	}
+}
+
+// countNonRecordingSpan counts a span started by a SpanContext that is not recording.
+func (h *Handler) countNonRecordingSpan() {
+	if h.diagnostics != nil {
+		h.diagnostics.nonRecordingSpans.Add(1)
+	}
+}
+
+// countSuppressedEvent counts a log event suppressed by an event budget.
+func (h *Handler) countSuppressedEvent() {
+	if h.diagnostics != nil {
+		h.diagnostics.suppressedEvents.Add(1)
+	}
+}
+
+// countDroppedEvent counts a span event dropped because the event queue was full.
+func (h *Handler) countDroppedEvent() {
+	if h.diagnostics != nil {
+		h.diagnostics.droppedEvents.Add(1)
+	}
+}
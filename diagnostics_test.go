@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// failingText is a value whose MarshalText always fails.
+type failingText struct{}
+
+func (failingText) MarshalText() ([]byte, error) {
+	return nil, errors.New("marshal failed")
+}
+
+// failingHandler is a slog.Handler whose Handle always fails.
+type failingHandler struct {
+	slog.Handler
+}
+
+func (failingHandler) Handle(context.Context, slog.Record) error {
+	return errors.New("write failed")
+}
+
+func TestDiagnostics(t *testing.T) {
+	otel.SetTracerProvider(trace.NewTracerProvider(trace.WithSpanProcessor(tracetest.NewSpanRecorder())))
+
+	t.Run("conversion fallback", func(t *testing.T) {
+		var errs []error
+		h := NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil),
+			WithErrorHandler(otel.ErrorHandlerFunc(func(err error) { errs = append(errs, err) })))
+		logger := slog.New(h)
+
+		span := NewSpanContext("span")
+		logger.InfoContext(span, "info", slog.Any("value", failingText{}))
+		span.End()
+
+		assert.Equal(t, int64(1), h.Stats().ConversionFallbacks)
+		if assert.Len(t, errs, 1) {
+			assert.ErrorContains(t, errs[0], `convert attribute "value": marshal failed`)
+		}
+	})
+
+	t.Run("format fallbacks", func(t *testing.T) {
+		var errs []error
+		h := NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil),
+			WithErrorHandler(otel.ErrorHandlerFunc(func(err error) { errs = append(errs, err) })))
+		logger := slog.New(h)
+
+		span := NewSpanContext("span")
+		logger.InfoContext(span, "info",
+			slog.Any("chan", make(chan int)),
+			slog.Any("struct", struct{ a int }{a: 1}),
+			slog.Any("structs", []struct{ A int }{{A: 1}}),
+			slog.Any("map", map[string]int{"a": 1}),
+			slog.Any("durations", []time.Duration{time.Second}))
+		span.End()
+
+		assert.Equal(t, int64(3), h.Stats().ConversionFallbacks)
+		assert.Empty(t, errs)
+	})
+
+	t.Run("next errors", func(t *testing.T) {
+		var errs []error
+		h := NewHandler(failingHandler{slog.NewJSONHandler(bytes.NewBuffer(nil), nil)},
+			WithErrorHandler(otel.ErrorHandlerFunc(func(err error) { errs = append(errs, err) })))
+
+		slog.New(h).Info("info")
+
+		assert.Equal(t, int64(1), h.Stats().NextErrors)
+		if assert.Len(t, errs, 1) {
+			assert.ErrorContains(t, errs[0], "next handler: write failed")
+		}
+	})
+
+	t.Run("nil next", func(t *testing.T) {
+		h := NewHandler(nil)
+		slog.New(h).Info("info")
+
+		assert.Equal(t, int64(1), h.Stats().NilNext)
+	})
+
+	t.Run("suppressed events", func(t *testing.T) {
+		h := NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil), WithEventBudget(EventBudget{MaxEvents: 1}))
+		logger := slog.New(h)
+
+		span := NewSpanContext("span")
+		logger.InfoContext(span, "first")
+		logger.InfoContext(span, "second")
+		logger.InfoContext(span, "third")
+		span.End()
+
+		assert.Equal(t, int64(2), h.Stats().SuppressedEvents)
+	})
+
+	t.Run("non-recording spans", func(t *testing.T) {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		defer otel.SetTracerProvider(trace.NewTracerProvider())
+
+		h := NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil))
+		logger := slog.New(h)
+
+		span := NewSpanContext("span")
+		logger.InfoContext(span, "info")
+		logger.InfoContext(span, "info")
+		span.End()
+
+		assert.Equal(t, int64(1), h.Stats().NonRecordingSpans)
+	})
+
+	t.Run("shared by derived handlers", func(t *testing.T) {
+		h := NewHandler(nil)
+		slog.New(h).WithGroup("group").With("key", "value").Info("info")
+
+		assert.Equal(t, int64(1), h.Stats().NilNext)
+	})
+}
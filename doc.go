@@ -13,13 +13,13 @@ consistency across your observability data.
 # Key Features
 
 Trace Context Integration:
-  - Automatic injection of trace and span IDs into log records
+  - Automatic injection of trace and span IDs, trace flags and parent span IDs into log records
   - Preservation of parent-child relationships between spans
   - Support for context propagation across service boundaries
 
 Flexible Configuration:
   - Customizable trace and span ID field names
-  - Configurable minimum log level for trace creation
+  - Configurable minimum log level for trace creation, adjustable at runtime
   - Optional span event recording
   - Support for mandatory spans that bypass log level filtering
 
@@ -27,6 +27,8 @@ Structured Data Support:
   - Full support for slog's group functionality
   - Hierarchical attribute preservation in both logs and spans
   - Type-aware attribute conversion between slog and OpenTelemetry formats
+  - Errors, fmt.Stringer, encoding.TextMarshaler and json.Marshaler values recorded as strings
+  - Maps and structs flattened into dotted attribute keys
 
 # Basic Usage
 
@@ -85,6 +87,11 @@ Structured Data Support:
 
 The handler supports several functional options for customization:
 
+WithFieldPreset(preset FieldPreset):
+
+	Sets the trace field keys and span event attribute keys of a log backend in one option:
+	ECS(), OTelLogDataModel(), Datadog() or GCPCloudLogging(projectID)
+
 WithTraceIDKey(key string):
 
 	Customizes the field name for trace IDs in log records
@@ -93,18 +100,119 @@ WithSpanIDKey(key string):
 
 	Customizes the field name for span IDs in log records
 
+WithRootTraceFields() and WithTraceFieldsGroup(name string):
+
+	Record the trace fields at the root level of each record, or in a dedicated root group,
+	instead of inside the groups opened with WithGroup
+
+WithTraceIDFormatter(formatter TraceIDFormatter):
+
+	Replaces the trace and span ID fields with vendor-specific correlation fields, e.g.
+	DatadogTraceIDFormatter, GCPTraceIDFormatter(projectID) or XRayTraceIDFormatter
+
+WithTraceFlagsKey(key string), WithParentSpanIDKey(key string) and WithTraceStateKey(key string):
+
+	Customize the field names for the trace flags ("trace_flags"), the parent span ID of spans
+	started by the handler ("parent_span_id") and the trace state (disabled by default).
+	An empty key disables a field
+
 WithSpanEventKey(key string):
 
 	Customizes the field name used when recording log entries as span events
 
-WithTraceLevel(level slog.Level):
+WithTraceLevel(level slog.Leveler):
+
+	Sets the minimum log level at which spans are created. Pass a *slog.LevelVar
+	to change it at runtime
+
+WithEventLevel(level slog.Leveler):
 
-	Sets the minimum log level at which spans are created
+	Sets the minimum log level recorded as span events, independently of the trace level.
+	SpanContext.WithEventLevel overrides it for a single span
+
+WithEventBudget(budget EventBudget):
+
+	Limits the log events recorded per span by count, rate or 1-in-N sampling of low levels,
+	and records a summary event with the number of suppressed events when the span ends
+
+WithCollapseRepeats(maxKeys int) and WithSuppressRepeats():
+
+	Record the first occurrence of identical log events on a span, and a summary with the
+	repeat count and first and last times when the SpanContext ends, optionally dropping the
+	repeated records from the next handler's output
 
 WithNoSpanEvents():
 
 	Disables the recording of log entries as span events
 
+WithConverter(converter Converter):
+
+	Teaches the handler how to convert custom types logged with slog.Any.
+	RegisterConverter[T] registers a conversion for a type package-wide
+
+WithEventAttrs(fields EventAttrs):
+
+	Selects the message, level, time and severity number attributes recorded on span events.
+	Span events are always timestamped with the record time
+
+WithEventNamer(namer EventNamer):
+
+	Names span events after the record message (EventNameMessage), level (EventNameLevel),
+	an attribute value (EventNameAttr) or a custom function instead of the span event key
+
+WithSampledLevel(level slog.Leveler) and WithBaggageLevel(key string):
+
+	Lower the level passed to the next handler for requests whose span is sampled, or whose
+	baggage carries a level such as "log.level=debug", to enable verbose logs per request
+
+WithUnsampledLevel(level slog.Leveler):
+
+	Drops records below level whose trace is not sampled, so log volume tracks trace sampling
+
+WithTraceBuffer(opts BufferOptions):
+
+	Holds low-level records per trace and only writes them when an error is logged in the same
+	trace, discarding them when the root span ends. Call Handler.Flush before exiting
+
+WithResource(res *resource.Resource, keys ...attribute.Key):
+
+	Adds resource attributes such as service.name, service.version and deployment.environment
+	to every record, bound once to the next handler
+
+WithMeterProvider(mp metric.MeterProvider):
+
+	Records counters of log records enabled in the next handler by level and logger group, of
+	error records, of spans started by the handler by whether they ended in error, and of
+	dropped or truncated event attributes. Records are counted with the span context of the record, so that
+	exemplars link error spikes back to their traces
+
+WithErrorHandler(handler otel.ErrorHandler):
+
+	Reports failed value conversions and errors of the next handler, which slog.Logger
+	otherwise ignores. Handler.Stats returns counters of these and of missing next handlers,
+	non-recording spans, dropped attributes and events suppressed by an event budget
+
+WithLocalTraceIDs():
+
+	Writes the IDs of spans that are not recorded, generating local W3C compatible IDs when
+	spans are started with a noop TracerProvider, instead of logging a one-time warning that
+	trace IDs are missing. Local IDs are propagated to child SpanContexts
+
+WithIDGenerator(generator IDGenerator):
+
+	Like WithLocalTraceIDs, generating the local IDs with the generator
+
+WithAsyncEvents(opts AsyncOptions):
+
+	Converts and records span events on a worker goroutine through a bounded queue that
+	blocks, drops the newest or drops the oldest event when full. Call Handler.Shutdown
+	before shutting down the TracerProvider to record the queued events
+
+WithDurationFormat(format DurationFormat):
+
+	Records time.Duration attributes as nanoseconds, microseconds, milliseconds,
+	seconds or a duration string
+
 # Best Practices
 
 1. Span Management:
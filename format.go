@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"encoding/binary"
+	"log/slog"
+	"strconv"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDFormatter returns the attributes that correlate a slog record with its span.
+// It replaces the trace ID and span ID fields of the handler; the trace flags, parent span ID
+// and trace state fields are recorded independently.
+type TraceIDFormatter func(spanCtx trace.SpanContext) []slog.Attr
+
+// DatadogTraceIDFormatter formats the trace and span IDs for Datadog log correlation.
+// It records the lower 64 bits of the trace ID as "dd.trace_id" and the span ID as "dd.span_id", both in decimal.
+func DatadogTraceIDFormatter() TraceIDFormatter {
+	return func(spanCtx trace.SpanContext) []slog.Attr {
+		traceID, spanID := spanCtx.TraceID(), spanCtx.SpanID()
+		return []slog.Attr{
+			slog.String("dd.trace_id", strconv.FormatUint(binary.BigEndian.Uint64(traceID[8:]), 10)),
+			slog.String("dd.span_id", strconv.FormatUint(binary.BigEndian.Uint64(spanID[:]), 10)),
+		}
+	}
+}
+
+// GCPTraceIDFormatter formats the trace and span IDs for Google Cloud Logging.
+// It records "logging.googleapis.com/trace" as projects/<projectID>/traces/<trace ID>,
+// "logging.googleapis.com/spanId" and "logging.googleapis.com/trace_sampled".
+func GCPTraceIDFormatter(projectID string) TraceIDFormatter {
+	return func(spanCtx trace.SpanContext) []slog.Attr {
+		return []slog.Attr{
+			slog.String("logging.googleapis.com/trace", "projects/"+projectID+"/traces/"+spanCtx.TraceID().String()),
+			slog.String("logging.googleapis.com/spanId", spanCtx.SpanID().String()),
+			slog.Bool("logging.googleapis.com/trace_sampled", spanCtx.IsSampled()),
+		}
+	}
+}
+
+// XRayTraceIDFormatter formats the trace ID for AWS X-Ray.
+// It records "xray_trace_id" as 1-<first 8 hex digits>-<remaining 24 hex digits> of the trace ID.
+func XRayTraceIDFormatter() TraceIDFormatter {
+	return func(spanCtx trace.SpanContext) []slog.Attr {
+		traceID := spanCtx.TraceID().String()
+		return []slog.Attr{
+			slog.String("xray_trace_id", "1-"+traceID[:8]+"-"+traceID[8:]),
+		}
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceIDFormatters(t *testing.T) {
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x5b, 0x8a, 0xa5, 0xa2, 0xd2, 0xc8, 0x72, 0xe8, 0x32, 0x1c, 0xf3, 0x73, 0x08, 0xd6, 0x9d, 0xf2},
+		SpanID:     trace.SpanID{0x05, 0x1f, 0xf8, 0xf8, 0x9e, 0x5b, 0x73, 0x43},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	tests := []struct {
+		name      string
+		formatter TraceIDFormatter
+		expected  []slog.Attr
+	}{
+		{
+			name:      "datadog",
+			formatter: DatadogTraceIDFormatter(),
+			expected: []slog.Attr{
+				slog.String("dd.trace_id", "3611028676639366642"),
+				slog.String("dd.span_id", "369287441160041283"),
+			},
+		},
+		{
+			name:      "gcp",
+			formatter: GCPTraceIDFormatter("my-project"),
+			expected: []slog.Attr{
+				slog.String("logging.googleapis.com/trace", "projects/my-project/traces/5b8aa5a2d2c872e8321cf37308d69df2"),
+				slog.String("logging.googleapis.com/spanId", "051ff8f89e5b7343"),
+				slog.Bool("logging.googleapis.com/trace_sampled", true),
+			},
+		},
+		{
+			name:      "xray",
+			formatter: XRayTraceIDFormatter(),
+			expected: []slog.Attr{
+				slog.String("xray_trace_id", "1-5b8aa5a2-d2c872e8321cf37308d69df2"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attrs := test.formatter(spanCtx)
+			assert.Equal(t, len(test.expected), len(attrs))
+			for i, attr := range attrs {
+				assert.True(t, test.expected[i].Equal(attr), "expected %v, got %v", test.expected[i], attr)
+			}
+		})
+	}
+}
+
+func TestHandlerTraceIDFormatter(t *testing.T) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	buf := bytes.NewBuffer(nil)
+	logger := slog.New(NewHandler(slog.NewJSONHandler(buf, nil), WithTraceIDFormatter(XRayTraceIDFormatter())))
+
+	ctx, span := otel.Tracer("trace").Start(context.Background(), "span")
+	logger.InfoContext(ctx, "with trace id formatter")
+	span.End()
+
+	traceID := span.SpanContext().TraceID().String()
+	assert.Contains(t, buf.String(), `"xray_trace_id":"1-`+traceID[:8]+`-`+traceID[8:]+`"`)
+	assert.NotContains(t, buf.String(), `"trace_id"`)
+	assert.NotContains(t, buf.String(), `"span_id"`)
+	assert.Contains(t, buf.String(), `"trace_flags":"01"`)
+}
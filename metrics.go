@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// meterName is the instrumentation scope name of the handler's metrics.
+const meterName = "github.com/yakumioto/otelslog"
+
+const (
+	// LogLevelKey is the metric attribute key of the record level.
+	LogLevelKey = attribute.Key("log.level")
+	// LoggerGroupKey is the metric attribute key of the dotted groups of the logger.
+	LoggerGroupKey = attribute.Key("logger.group")
+	// SpanErrorKey is the metric attribute key reporting whether a span ended in error.
+	SpanErrorKey = attribute.Key("error")
+	// DropReasonKey is the metric attribute key of the reason an attribute was dropped or truncated.
+	DropReasonKey = attribute.Key("reason")
+)
+
+// Reasons for dropped or truncated attributes.
+const (
+	dropReasonEmpty     = "empty"
+	dropReasonInvalid   = "invalid"
+	dropReasonTruncated = "truncated"
+)
+
+// handlerMetrics holds the instruments recorded by the handler.
+type handlerMetrics struct {
+	records      metric.Int64Counter
+	errors       metric.Int64Counter
+	spans        metric.Int64Counter
+	droppedAttrs metric.Int64Counter
+}
+
+// newHandlerMetrics creates the handler's instruments from the meter provider.
+func newHandlerMetrics(mp metric.MeterProvider) (*handlerMetrics, error) {
+	meter := mp.Meter(meterName)
+
+	records, err := meter.Int64Counter("otelslog.records",
+		metric.WithDescription("Number of log records enabled in the next handler, by level and logger group."),
+		metric.WithUnit("{record}"))
+	if err != nil {
+		return nil, err
+	}
+
+	errors, err := meter.Int64Counter("otelslog.errors",
+		metric.WithDescription("Number of error level log records enabled in the next handler, by logger group."),
+		metric.WithUnit("{record}"))
+	if err != nil {
+		return nil, err
+	}
+
+	spans, err := meter.Int64Counter("otelslog.spans",
+		metric.WithDescription("Number of spans started by the handler that ended, by whether they ended in error."),
+		metric.WithUnit("{span}"))
+	if err != nil {
+		return nil, err
+	}
+
+	droppedAttrs, err := meter.Int64Counter("otelslog.attributes.dropped",
+		metric.WithDescription("Number of span event attributes dropped or truncated during conversion."),
+		metric.WithUnit("{attribute}"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &handlerMetrics{
+		records:      records,
+		errors:       errors,
+		spans:        spans,
+		droppedAttrs: droppedAttrs,
+	}, nil
+}
+
+// WithMeterProvider records metrics about the handler with the meter provider: the number of log records
+// enabled in the next handler by level and logger group, the number of those error records by logger group,
+// the number of spans started by the handler by whether they ended in error, and the number of span event
+// attributes dropped or truncated during conversion.
+func WithMeterProvider(mp metric.MeterProvider) Options {
+	return func(h *Handler) {
+		if mp == nil {
+			return
+		}
+
+		metrics, err := newHandlerMetrics(mp)
+		if err != nil {
+			otel.Handle(err)
+			return
+		}
+		h.metrics = metrics
+	}
+}
+
+// recordLog counts a log record, and an error record if the level is at or above slog.LevelError.
+// Records the next handler would filter out are not counted.
+// ctx carries the span of the record, so that exemplars of the measurements link back to its trace.
+func (h *Handler) recordLog(ctx context.Context, level slog.Level) {
+	if h.metrics == nil || !h.nextEnabled(ctx, level) {
+		return
+	}
+
+	group := LoggerGroupKey.String(h.groupPrefix)
+	h.metrics.records.Add(ctx, 1, metric.WithAttributes(LogLevelKey.String(level.String()), group))
+	if level >= slog.LevelError {
+		h.metrics.errors.Add(ctx, 1, metric.WithAttributes(group))
+	}
+}
+
+// recordSpanEnd counts a span started by the handler when it ends.
+func (h *Handler) recordSpanEnd(failed bool) {
+	if h.metrics == nil {
+		return
+	}
+
+	h.metrics.spans.Add(context.Background(), 1, metric.WithAttributes(SpanErrorKey.Bool(failed)))
+}
+
+// recordDroppedAttribute counts an attribute dropped or truncated during conversion.
+func (h *Handler) recordDroppedAttribute(reason string) {
+	h.countDroppedAttribute()
+	if h.metrics == nil {
+		return
+	}
+
+	h.metrics.droppedAttrs.Add(context.Background(), 1, metric.WithAttributes(DropReasonKey.String(reason)))
+}
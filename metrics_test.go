@@ -0,0 +1,156 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// collectSums collects the int64 sums of the reader keyed by metric name.
+func collectSums(t *testing.T, reader sdkmetric.Reader) map[string][]metricdata.DataPoint[int64] {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	sums := make(map[string][]metricdata.DataPoint[int64])
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+				sums[m.Name] = sum.DataPoints
+			}
+		}
+	}
+	return sums
+}
+
+// dataPointValue returns the value of the data point with the attributes, or zero if there is none.
+func dataPointValue(points []metricdata.DataPoint[int64], attrs ...attribute.KeyValue) int64 {
+	set := attribute.NewSet(attrs...)
+	for _, point := range points {
+		if point.Attributes.Equals(&set) {
+			return point.Value
+		}
+	}
+	return 0
+}
+
+func TestWithMeterProvider(t *testing.T) {
+	otel.SetTracerProvider(trace.NewTracerProvider(trace.WithSpanProcessor(tracetest.NewSpanRecorder())))
+
+	setup := func() (*slog.Logger, sdkmetric.Reader) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		logger := slog.New(NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil), WithMeterProvider(mp)))
+		return logger, reader
+	}
+
+	t.Run("records", func(t *testing.T) {
+		logger, reader := setup()
+
+		logger.Info("info")
+		logger.Info("info")
+		logger.WithGroup("http").WithGroup("request").Warn("warn")
+
+		records := collectSums(t, reader)["otelslog.records"]
+		assert.Equal(t, int64(2), dataPointValue(records, LogLevelKey.String("INFO"), LoggerGroupKey.String("")))
+		assert.Equal(t, int64(1), dataPointValue(records, LogLevelKey.String("WARN"), LoggerGroupKey.String("http.request")))
+	})
+
+	t.Run("records filtered by the next handler", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		next := slog.NewJSONHandler(bytes.NewBuffer(nil), &slog.HandlerOptions{Level: slog.LevelWarn})
+		logger := slog.New(NewHandler(next, WithMeterProvider(mp)))
+
+		logger.Info("info")
+		logger.Warn("warn")
+
+		records := collectSums(t, reader)["otelslog.records"]
+		assert.Equal(t, int64(0), dataPointValue(records, LogLevelKey.String("INFO"), LoggerGroupKey.String("")))
+		assert.Equal(t, int64(1), dataPointValue(records, LogLevelKey.String("WARN"), LoggerGroupKey.String("")))
+	})
+
+	t.Run("spans", func(t *testing.T) {
+		logger, reader := setup()
+
+		ok := NewSpanContext("ok")
+		logger.InfoContext(ok, "info")
+		ok.End()
+
+		failed := NewSpanContext("failed")
+		logger.InfoContext(failed, "info")
+		logger.ErrorContext(failed, "error")
+		failed.End()
+
+		spans := collectSums(t, reader)["otelslog.spans"]
+		assert.Equal(t, int64(1), dataPointValue(spans, SpanErrorKey.Bool(false)))
+		assert.Equal(t, int64(1), dataPointValue(spans, SpanErrorKey.Bool(true)))
+	})
+
+	t.Run("dropped attributes", func(t *testing.T) {
+		logger, reader := setup()
+
+		type node struct {
+			Next *node `json:"next"`
+		}
+		deep := &node{}
+		for cur, i := deep, 0; i < maxFlattenDepth; i++ {
+			cur.Next = &node{}
+			cur = cur.Next
+		}
+
+		span := NewSpanContext("span")
+		logger.InfoContext(span, "info", slog.Any("deep", deep))
+		span.End()
+
+		dropped := collectSums(t, reader)["otelslog.attributes.dropped"]
+		assert.Equal(t, int64(1), dataPointValue(dropped, DropReasonKey.String(dropReasonTruncated)))
+	})
+
+	t.Run("error exemplars", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(reader),
+			sdkmetric.WithExemplarFilter(exemplar.TraceBasedFilter))
+		logger := slog.New(NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil), WithMeterProvider(mp)))
+
+		logger.Error("error without span")
+
+		span := NewSpanContext("span")
+		logger.InfoContext(span, "info")
+		logger.ErrorContext(span, "error")
+		span.End()
+
+		errors := collectSums(t, reader)["otelslog.errors"]
+		if assert.Len(t, errors, 1) {
+			assert.Equal(t, int64(2), errors[0].Value)
+			if assert.Len(t, errors[0].Exemplars, 1) {
+				traceID := span.SpanContext().TraceID()
+				spanID := span.SpanContext().SpanID()
+				assert.Equal(t, traceID[:], errors[0].Exemplars[0].TraceID)
+				assert.Equal(t, spanID[:], errors[0].Exemplars[0].SpanID)
+			}
+		}
+	})
+
+	t.Run("nil provider", func(t *testing.T) {
+		h := NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil), WithMeterProvider(nil))
+		assert.Nil(t, h.metrics)
+	})
+}
@@ -0,0 +1,11 @@
+//go:build !race
+
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+// raceEnabled reports whether the tests run with the race detector, which makes allocation counts unreliable.
+const raceEnabled = false
@@ -7,14 +7,15 @@ package otelslog
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"slices"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -22,7 +23,15 @@ import (
 // Options is a functional option for the Handler.
 type Options func(*Handler)
 
+// WithFieldPreset sets the trace field keys and the span event attribute keys of a log backend in one option,
+// e.g. ECS(), OTelLogDataModel(), Datadog() or GCPCloudLogging(projectID).
+// Options following it can override individual keys.
+func WithFieldPreset(preset FieldPreset) Options {
+	return preset.apply
+}
+
 // WithTraceIDKey sets the key used to record the trace ID in slog records.
+// An empty key disables the field.
 func WithTraceIDKey(key string) Options {
 	return func(h *Handler) {
 		h.traceIDKey = key
@@ -30,12 +39,62 @@ func WithTraceIDKey(key string) Options {
 }
 
 // WithSpanIDKey sets the key used to record the span ID in slog records.
+// An empty key disables the field.
 func WithSpanIDKey(key string) Options {
 	return func(h *Handler) {
 		h.spanIDKey = key
 	}
 }
 
+// WithTraceIDFormatter replaces the trace ID and span ID fields with the attributes returned by the formatter,
+// e.g. DatadogTraceIDFormatter, GCPTraceIDFormatter or XRayTraceIDFormatter.
+func WithTraceIDFormatter(formatter TraceIDFormatter) Options {
+	return func(h *Handler) {
+		h.traceIDFormatter = formatter
+	}
+}
+
+// WithRootTraceFields records the trace fields at the root level of each record,
+// instead of inside the groups opened with WithGroup.
+func WithRootTraceFields() Options {
+	return func(h *Handler) {
+		h.rootTraceFields = true
+	}
+}
+
+// WithTraceFieldsGroup records the trace fields inside a dedicated group at the root level of each record,
+// independent of the groups opened with WithGroup.
+func WithTraceFieldsGroup(name string) Options {
+	return func(h *Handler) {
+		h.rootTraceFields = true
+		h.traceFieldsGroup = name
+	}
+}
+
+// WithTraceFlagsKey sets the key used to record the W3C trace flags, e.g. "01" for sampled, in slog records.
+// An empty key disables the field.
+func WithTraceFlagsKey(key string) Options {
+	return func(h *Handler) {
+		h.traceFlagsKey = key
+	}
+}
+
+// WithParentSpanIDKey sets the key used to record the parent span ID in slog records.
+// It is only recorded for spans started by the handler that have a parent. An empty key disables the field.
+func WithParentSpanIDKey(key string) Options {
+	return func(h *Handler) {
+		h.parentSpanIDKey = key
+	}
+}
+
+// WithTraceStateKey sets the key used to record the W3C trace state string in slog records.
+// The trace state is not recorded by default.
+func WithTraceStateKey(key string) Options {
+	return func(h *Handler) {
+		h.traceStateKey = key
+	}
+}
+
 // WithSpanEventKey sets the key used to record slog attributes as span events.
 func WithSpanEventKey(key string) Options {
 	return func(h *Handler) {
@@ -43,6 +102,14 @@ func WithSpanEventKey(key string) Options {
 	}
 }
 
+// WithEventNamer sets the function used to name span events.
+// Records for which the namer returns an empty string use the span event key.
+func WithEventNamer(namer EventNamer) Options {
+	return func(h *Handler) {
+		h.eventNamer = namer
+	}
+}
+
 // WithNoSpanEvents disables recording slog attributes as span events.
 func WithNoSpanEvents() Options {
 	return func(h *Handler) {
@@ -50,21 +117,181 @@ func WithNoSpanEvents() Options {
 	}
 }
 
-func WithTraceLevel(level slog.Level) Options {
+// WithEventAttrs selects which record fields are recorded as span event attributes.
+// The default is EventMessage | EventLevel; the record time is always used as the event timestamp.
+func WithEventAttrs(fields EventAttrs) Options {
 	return func(h *Handler) {
+		h.eventAttrs = fields
+	}
+}
+
+// WithTraceLevel sets the minimum level of slog records that start a span.
+// The level is read on every record, so a *slog.LevelVar can change it at runtime.
+func WithTraceLevel(level slog.Leveler) Options {
+	return func(h *Handler) {
+		if level == nil {
+			level = slog.LevelInfo
+		}
 		h.traceLevel = level
 	}
 }
 
+// WithEventLevel sets the minimum level of slog records recorded as span events.
+// It is independent of the trace level, and can be overridden per span with SpanContext.WithEventLevel.
+// By default, records of every level are recorded. Like the trace level, it is read on every record.
+func WithEventLevel(level slog.Leveler) Options {
+	return func(h *Handler) {
+		h.eventLevel = level
+	}
+}
+
+// WithEventBudget limits the number of log events recorded on each span started by the handler.
+// When such a span ends, a summary event records how many log events were suppressed.
+func WithEventBudget(budget EventBudget) Options {
+	return func(h *Handler) {
+		h.eventBudget = budget
+	}
+}
+
+// WithCollapseRepeats collapses identical log events on a span started by the handler.
+// The first occurrence of an event is recorded immediately. Later events with the same name, message and
+// attributes are only counted, and a summary event with the repeat count and the first and last times is
+// recorded when the SpanContext ends. Summaries are lost if the span is ended in any other way.
+// At most maxKeys distinct events are tracked per span, further events are always recorded.
+// A maxKeys of zero or less uses a default of 128.
+func WithCollapseRepeats(maxKeys int) Options {
+	return func(h *Handler) {
+		h.collapseRepeats = true
+		h.maxRepeatKeys = maxKeys
+	}
+}
+
+// WithSuppressRepeats stops records collapsed by WithCollapseRepeats from being passed
+// to the next handler after their first occurrence.
+func WithSuppressRepeats() Options {
+	return func(h *Handler) {
+		h.suppressRepeats = true
+	}
+}
+
+// WithSampledLevel lowers the minimum level passed to the next handler for records whose
+// context carries a sampled span. Records at or above level are handled even if the next
+// handler is not enabled for them, e.g. WithSampledLevel(slog.LevelDebug) enables debug
+// logs for sampled requests only.
+func WithSampledLevel(level slog.Leveler) Options {
+	return func(h *Handler) {
+		h.sampledLevel = level
+	}
+}
+
+// WithBaggageLevel lowers the minimum level passed to the next handler for records whose
+// context carries the baggage member key, whose value names a slog.Level such as "debug".
+// It lets a single request enable verbose logging end-to-end through baggage propagation,
+// e.g. WithBaggageLevel("log.level") with the baggage "log.level=debug".
+func WithBaggageLevel(key string) Options {
+	return func(h *Handler) {
+		h.baggageLevelKey = key
+	}
+}
+
+// WithUnsampledLevel applies trace sampling decisions to logs. Records whose context carries
+// a span that is not sampled are only passed to the next handler at or above level, e.g.
+// WithUnsampledLevel(slog.LevelWarn) keeps warnings and errors but drops info and debug
+// records of unsampled traces. Records without a span are not affected.
+func WithUnsampledLevel(level slog.Leveler) Options {
+	return func(h *Handler) {
+		h.unsampledLevel = level
+	}
+}
+
+// WithTraceBuffer enables tail-based buffering of log records per trace.
+// Records below the buffer level are held back and only passed to the next handler if a record
+// at the flush level occurs in the same trace; they are discarded when the root SpanContext of
+// the trace ends, when the trace expires, or when it is evicted by the memory limits.
+// Call Handler.Flush to pass all held records to the next handler.
+func WithTraceBuffer(opts BufferOptions) Options {
+	return func(h *Handler) {
+		h.buffer = newRecordBuffer(opts)
+	}
+}
+
+// WithDurationFormat sets how time.Duration values are recorded as span event attributes.
+// The default is DurationNanoseconds.
+func WithDurationFormat(format DurationFormat) Options {
+	return func(h *Handler) {
+		h.durationFormat = format
+	}
+}
+
+// WithConverter adds a converter consulted before the default conversion of values logged with slog.Any.
+// Converters are tried in the order they are added, ahead of those registered with RegisterConverter.
+func WithConverter(converter Converter) Options {
+	return func(h *Handler) {
+		h.converters = append(h.converters, converter)
+	}
+}
+
+// EventAttrs is a set of record fields recorded as span event attributes.
+type EventAttrs uint8
+
+const (
+	// EventMessage records the log message, under the slog.MessageKey attribute by default.
+	EventMessage EventAttrs = 1 << iota
+	// EventLevel records the level name, under the slog.LevelKey attribute by default.
+	EventLevel
+	// EventTime records the RFC 3339 formatted record time, under the slog.TimeKey attribute by default.
+	EventTime
+	// EventSeverity records the OpenTelemetry severity number of the level,
+	// under the SeverityNumberKey attribute by default.
+	EventSeverity
+)
+
+// EventNamer returns the name of the span event recorded for a slog record.
+type EventNamer func(record slog.Record) string
+
+// EventNameMessage names span events after the record message.
+func EventNameMessage() EventNamer {
+	return func(record slog.Record) string {
+		return record.Message
+	}
+}
+
+// EventNameLevel names span events after the record level, e.g. "INFO".
+func EventNameLevel() EventNamer {
+	return func(record slog.Record) string {
+		return record.Level.String()
+	}
+}
+
+// EventNameAttr names span events after the string value of the record attribute with the given key.
+// Records without the attribute use the span event key.
+func EventNameAttr(key string) EventNamer {
+	return func(record slog.Record) string {
+		name := ""
+		record.Attrs(func(attr slog.Attr) bool {
+			if attr.Key != key {
+				return true
+			}
+			name = attr.Value.Resolve().String()
+			return false
+		})
+		return name
+	}
+}
+
+// SeverityNumberKey is the span event attribute key of the OpenTelemetry severity number.
+const SeverityNumberKey = "severity_number"
+
 // NewHandler creates a new slog.Handler with the given options.
 func NewHandler(handler slog.Handler, opts ...Options) *Handler {
 	h := &Handler{
-		traceIDKey:   "trace_id",
-		spanIDKey:    "span_id",
 		spanEventKey: "log",
 		spanEvent:    true,
+		traceLevel:   slog.LevelInfo,
+		diagnostics:  &diagnostics{},
 		Next:         handler,
 	}
+	defaultFields.apply(h)
 
 	for _, opt := range opts {
 		opt(h)
@@ -78,12 +305,24 @@ func NewHandler(handler slog.Handler, opts ...Options) *Handler {
 // and options for including baggage attributes in slog records.
 type Handler struct {
 	// OpenTelemetry trace context keys
-	traceIDKey string
-	spanIDKey  string
+	traceIDKey      string
+	spanIDKey       string
+	traceFlagsKey   string
+	parentSpanIDKey string
+	traceStateKey   string
+
+	// Formats the trace and span ID fields, replacing the trace and span ID keys
+	traceIDFormatter TraceIDFormatter
+
+	// slog attributes converted for span events, SpanContext attributes and the dotted prefix of the group keys
+	attrs       []attribute.KeyValue
+	spanAttrs   []slog.Attr
+	groupPrefix string
 
-	// slog attributes and group keys
-	attrs     []slog.Attr
-	groupKeys []string
+	// Places trace fields at the root level or in a dedicated group, independent of the groups
+	rootTraceFields  bool
+	traceFieldsGroup string
+	groups           []groupFrame
 
 	// Key used to record slog attributes as span events
 	spanEventKey string
@@ -91,8 +330,57 @@ type Handler struct {
 	// Controls whether slog attributes should be recorded as span events
 	spanEvent bool
 
+	// Record fields recorded as span event attributes, and their keys
+	eventAttrs       EventAttrs
+	eventMessageKey  string
+	eventLevelKey    string
+	eventTimeKey     string
+	eventSeverityKey string
+
+	// Names span events, defaults to the span event key
+	eventNamer EventNamer
+
 	// Controls the level of slog records to be traced
-	traceLevel slog.Level
+	traceLevel slog.Leveler
+
+	// Controls the level of slog records to be recorded as span events
+	eventLevel slog.Leveler
+
+	// Limits the log events recorded on spans started by the handler
+	eventBudget EventBudget
+
+	// Lower the level passed to the next handler for sampled or flagged requests
+	sampledLevel    slog.Leveler
+	baggageLevelKey string
+
+	// Minimum level passed to the next handler for records of unsampled traces
+	unsampledLevel slog.Leveler
+
+	// Buffers records per trace until the trace fails, shared with derived handlers
+	buffer *recordBuffer
+
+	// Records metrics about the handler, nil if disabled
+	metrics *handlerMetrics
+
+	// Counts and reports the problems the handler works around
+	diagnostics *diagnostics
+
+	// Records span events asynchronously, nil if disabled
+	events *eventQueue
+
+	// Generates local trace IDs for spans that are not recorded, nil if disabled
+	idGenerator IDGenerator
+
+	// Controls collapsing of repeated log events on spans started by the handler
+	collapseRepeats bool
+	suppressRepeats bool
+	maxRepeatKeys   int
+
+	// Controls how time.Duration values are converted to span event attributes
+	durationFormat DurationFormat
+
+	// Custom converters for values logged with slog.Any
+	converters []Converter
 
 	// Next slog.Handler in the chain
 	Next slog.Handler
@@ -106,40 +394,135 @@ func (h *Handler) Enabled(_ context.Context, _ slog.Level) bool {
 // Handle processes the slog.Record and adds OpenTelemetry attributes and events.
 func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
 	ctx, record = h.handleTrace(ctx, record)
+	h.recordLog(ctx, record.Level)
 
-	if err := h.handleSpan(ctx, &record); err != nil {
+	numAttrs := record.NumAttrs()
+	forward, err := h.handleSpan(ctx, &record)
+	if err != nil || !forward {
 		return err
 	}
 
+	if h.rootTraceFields {
+		record = h.rootRecord(record, numAttrs)
+	}
+
+	if h.buffer != nil && h.nextEnabled(ctx, record.Level) {
+		records, buffered := h.buffer.handle(h, ctx, record)
+		if buffered {
+			return nil
+		}
+		if err := handleBuffered(records); err != nil {
+			return err
+		}
+	}
+
 	return h.nextHandle(ctx, record)
 }
 
 // WithAttrs returns a new slog.Handler that includes the given slog.Attrs.
+// SpanContext attributes are kept by the handler to start spans, the others are passed to the next handler
+// and converted once to be recorded on span events under the current groups.
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &Handler{
-		attrs:        attrs,
-		groupKeys:    h.groupKeys,
-		traceIDKey:   h.traceIDKey,
-		spanIDKey:    h.spanIDKey,
-		spanEventKey: h.spanEventKey,
-		spanEvent:    h.spanEvent,
-		traceLevel:   h.traceLevel,
-		Next:         h.Next,
+	h2 := h.clone()
+
+	plain := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		if _, ok := spanContextValue(attr.Value); ok {
+			h2.spanAttrs = append(slices.Clip(h2.spanAttrs), attr)
+			continue
+		}
+		plain = append(plain, attr)
+	}
+
+	if len(plain) > 0 {
+		h2.attrs = slices.Clip(h2.attrs)
+		for _, attr := range plain {
+			h.convertAttrsPrefix(attr, func(kv attribute.KeyValue) {
+				if kv == (attribute.KeyValue{}) {
+					h.recordDroppedAttribute(dropReasonEmpty)
+					return
+				}
+				h2.attrs = append(h2.attrs, kv)
+			}, h.groupPrefix)
+		}
+	}
+
+	if len(plain) == 0 || h.Next == nil {
+		return h2
+	}
+
+	if h.rootTraceFields && len(h.groups) > 0 {
+		h2.groups = slices.Clone(h.groups)
+		last := &h2.groups[len(h2.groups)-1]
+		last.attrs = append(slices.Clip(last.attrs), plain...)
+	} else {
+		h2.Next = h.Next.WithAttrs(plain)
 	}
+
+	return h2
 }
 
 // WithGroup returns a new slog.Handler that includes the given slog.Handler.
 func (h *Handler) WithGroup(name string) slog.Handler {
-	return &Handler{
-		attrs:        h.attrs,
-		groupKeys:    append(h.groupKeys, name),
-		traceIDKey:   h.traceIDKey,
-		spanIDKey:    h.spanIDKey,
-		spanEventKey: h.spanEventKey,
-		spanEvent:    h.spanEvent,
-		traceLevel:   h.traceLevel,
-		Next:         h.Next.WithGroup(name),
+	h2 := h.clone()
+	if h.groupPrefix == "" {
+		h2.groupPrefix = name
+	} else {
+		h2.groupPrefix = h.groupPrefix + "." + name
+	}
+	switch {
+	case h.rootTraceFields:
+		h2.groups = append(slices.Clip(h.groups), groupFrame{name: name})
+	case h.Next != nil:
+		h2.Next = h.Next.WithGroup(name)
 	}
+	return h2
+}
+
+// clone returns a shallow copy of the handler that shares its configuration.
+func (h *Handler) clone() *Handler {
+	h2 := *h
+	return &h2
+}
+
+// groupFrame is a group opened with WithGroup, together with the attributes added while it was the innermost group.
+// It is used to place trace fields at the root level, outside the groups of the logger.
+type groupFrame struct {
+	name  string
+	attrs []slog.Attr
+}
+
+// rootRecord nests the first numAttrs attributes of the record inside the handler's groups and places
+// the trace fields that follow them at the root level, or inside the trace fields group.
+func (h *Handler) rootRecord(record slog.Record, numAttrs int) slog.Record {
+	if len(h.groups) == 0 && h.traceFieldsGroup == "" {
+		return record
+	}
+
+	attrs := make([]slog.Attr, 0, numAttrs)
+	fields := make([]slog.Attr, 0, record.NumAttrs()-numAttrs)
+	record.Attrs(func(attr slog.Attr) bool {
+		if len(attrs) < numAttrs {
+			attrs = append(attrs, attr)
+		} else {
+			fields = append(fields, attr)
+		}
+		return true
+	})
+
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		groupAttrs := append(slices.Clip(h.groups[i].attrs), attrs...)
+		attrs = []slog.Attr{{Key: h.groups[i].name, Value: slog.GroupValue(groupAttrs...)}}
+	}
+
+	newRecord := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	newRecord.AddAttrs(attrs...)
+	if h.traceFieldsGroup != "" && len(fields) > 0 {
+		newRecord.AddAttrs(slog.Attr{Key: h.traceFieldsGroup, Value: slog.GroupValue(fields...)})
+	} else {
+		newRecord.AddAttrs(fields...)
+	}
+	return newRecord
 }
 
 // handleTrace handles the trace context for the slog record.
@@ -147,213 +530,480 @@ func (h *Handler) WithGroup(name string) slog.Handler {
 // Otherwise, it retrieves the trace span from the slog attributes and updates the context and record.
 // It returns the updated context and record.
 func (h *Handler) handleTrace(ctx context.Context, record slog.Record) (context.Context, slog.Record) {
-	traceSpan, attrs := h.getTraceSpan(h.collectAttributes(record))
+	traceSpan, traceName, record := h.getTraceSpan(record)
 	if traceSpan != nil {
-		if traceSpan.Context != nil {
-			ctx = h.traceStart(traceSpan.Context, record.Level, traceSpan)
-		} else {
-			ctx = h.traceStart(ctx, record.Level, traceSpan)
-		}
-		newRecord := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
-		newRecord.AddAttrs(attrs...)
-		return ctx, newRecord
+		return h.traceStart(ctx, record.Level, traceSpan, traceName), record
 	}
 
 	if spanCtx, ok := ctx.(*SpanContext); ok {
-		if spanCtx.Context == nil {
-			spanCtx.Context = context.Background()
-		}
-		ctx = h.traceStart(spanCtx.Context, record.Level, spanCtx)
-		return ctx, record
+		return h.traceStart(context.Background(), record.Level, spanCtx, ""), record
 	}
 
 	return ctx, record
 }
 
+// minTraceLevel returns the trace level, falling back to slog.LevelInfo for a Handler
+// built without NewHandler.
+func (h *Handler) minTraceLevel() slog.Level {
+	if h.traceLevel == nil {
+		return slog.LevelInfo
+	}
+
+	return h.traceLevel.Level()
+}
+
 // traceStart starts the span and returns the updated context.
 // If the span is nil, it returns the context unchanged.
-// If the level is greater than or equal to the trace level, it starts the span.
+// The span is started from the SpanContext's own context if it has one, and from ctx otherwise.
+// If the span has already been started, it returns the state of the span so the record is attached to it.
+// If the span has ended, a new span is started in its place.
+// If the level is greater than or equal to the trace level, it starts the span, with name as its trace name if set.
 // If the span must be created, it ensures the span is created.
-func (h *Handler) traceStart(ctx context.Context, level slog.Level, span *SpanContext) context.Context {
+func (h *Handler) traceStart(ctx context.Context, level slog.Level, span *SpanContext, name string) context.Context {
 	if span == nil {
 		return ctx
 	}
+	// A context carrying the SpanContext cannot be the parent of its span, as Value takes the lock held below.
+	if spanContextFromContext(ctx) == span {
+		ctx = context.Background()
+	}
+
+	span.mu.Lock()
+	defer span.mu.Unlock()
+
+	if span.state != nil && !span.ended {
+		return span.state
+	}
+	if span.Context != nil {
+		ctx = span.Context
+	}
+	if span.ended {
+		// The span has ended, so a new span is started with the parent of the ended one.
+		ctx = span.base
+	}
 
-	if level >= h.traceLevel || span.must {
-		span.Context, span.Span = otel.Tracer(span.traceName).Start(ctx, span.spanName)
-		return span
+	if level >= h.minTraceLevel() || span.must {
+		if name != "" {
+			span.traceName = name
+		}
+		state := &spanState{owner: span, parent: trace.SpanContextFromContext(ctx)}
+		state.Context, state.span = otel.Tracer(span.traceName).Start(ctx, span.spanName)
+		state.recording = state.span.IsRecording()
+		if !state.recording {
+			h.countNonRecordingSpan()
+			h.handleNonRecordingSpan(state, ctx)
+		}
+		h.initSpanState(span, state)
+
+		span.base, span.ended, span.state = ctx, false, state
+		span.Context, span.Span = state.Context, state.span
+		return state
 	}
 
 	return ctx
 }
 
-// collectAttributes collects slog attributes from the record and the handler's attributes.
-// It returns the collected attributes.
-func (h *Handler) collectAttributes(record slog.Record) []slog.Attr {
-	attrs := make([]slog.Attr, 0, record.NumAttrs()+len(h.attrs))
-	attrs = append(attrs, h.attrs...)
+// initSpanState sets up the state of a span started by the handler,
+// and registers the hooks that finalize it when the span ends.
+// Root spans discard the records buffered for their trace when they end.
+func (h *Handler) initSpanState(span *SpanContext, state *spanState) {
+	if h.events != nil {
+		// Must run first, so that the other hooks see the span events queued before End.
+		// Each span has its own WaitGroup, as a new span may start before End of the previous one returns.
+		pending := &sync.WaitGroup{}
+		state.pending = pending
+		span.endHooks = append(span.endHooks, func(trace.Span) { pending.Wait() })
+	}
+
+	if h.collapseRepeats {
+		repeats := newSpanRepeats(h.maxRepeatKeys)
+		state.repeats = repeats
+		span.endHooks = append(span.endHooks, repeats.flush)
+	}
+
+	if h.eventBudget.enabled() {
+		budget, name := newSpanBudget(h.eventBudget), h.spanEventKey+".suppressed"
+		state.budget = budget
+		span.endHooks = append(span.endHooks, func(s trace.Span) { budget.summarize(s, name) })
+	}
+
+	if h.buffer != nil && !state.parent.IsValid() {
+		buffer := h.buffer
+		span.endHooks = append(span.endHooks, func(s trace.Span) { buffer.discard(s.SpanContext().TraceID()) })
+	}
+
+	if h.metrics != nil {
+		span.endHooks = append(span.endHooks, func(trace.Span) { h.recordSpanEnd(state.failed.Load()) })
+	}
+}
+
+// getTraceSpan retrieves the SpanContext and the key it was logged with from the record's attributes,
+// or else from the handler's attributes. A SpanContext found in the record is removed from the returned record.
+// It returns nil and the original record if no SpanContext is found, without allocating.
+func (h *Handler) getTraceSpan(record slog.Record) (*SpanContext, string, slog.Record) {
+	var span *SpanContext
+	var key string
+	index, i := -1, 0
 	record.Attrs(func(attr slog.Attr) bool {
-		attrs = append(attrs, attr)
+		if s, ok := spanContextValue(attr.Value); ok {
+			span, key, index = s, attr.Key, i
+			return false
+		}
+		i++
 		return true
 	})
-	return attrs
-}
 
-// getTraceSpan retrieves the TraceSpan from the attributes and returns it along with the remaining attributes.
-// It returns nil and the original attributes if no TraceSpan is found.
-func (h *Handler) getTraceSpan(attrs []slog.Attr) (*SpanContext, []slog.Attr) {
-	for i, attr := range attrs {
-		if span, ok := attr.Value.Resolve().Any().(*SpanContext); ok {
-			span.traceName = attr.Key
-			return span, slices.Delete(attrs, i, i+1)
+	if span != nil {
+		newRecord := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+		i = 0
+		record.Attrs(func(attr slog.Attr) bool {
+			if i != index {
+				newRecord.AddAttrs(attr)
+			}
+			i++
+			return true
+		})
+		return span, key, newRecord
+	}
+
+	for _, attr := range h.spanAttrs {
+		if span, ok := spanContextValue(attr.Value); ok {
+			return span, attr.Key, record
 		}
 	}
-	return nil, attrs
+
+	return nil, "", record
+}
+
+// spanContextValue returns the SpanContext held by the value, if any.
+// Only the values of slog.Any are inspected, so that LogValuers are resolved once, by the next handler.
+func spanContextValue(value slog.Value) (*SpanContext, bool) {
+	if value.Kind() != slog.KindAny {
+		return nil, false
+	}
+	span, ok := value.Any().(*SpanContext)
+	return span, ok
 }
 
 // nextHandle calls the next slog.Handler in the chain if it exists and is enabled for the given slog.Level.
+// The next handler is also called if the context lowers the level through WithSampledLevel or WithBaggageLevel.
 // It returns nil if the next handler does not exist or is not enabled.
+// A missing next handler is counted in Stats, and the errors of the next handler are reported to the error handler.
 func (h *Handler) nextHandle(ctx context.Context, record slog.Record) error {
-	if h.Next != nil && h.Next.Enabled(ctx, record.Level) {
-		return h.Next.Handle(ctx, record)
+	if h.Next == nil {
+		h.countNilNext()
+		return nil
+	}
+
+	if !h.nextEnabled(ctx, record.Level) {
+		return nil
 	}
 
+	if err := h.Next.Handle(ctx, record); err != nil {
+		h.reportNextError(err)
+		return err
+	}
 	return nil
 }
 
+// nextEnabled reports whether the next handler exists and handles records of the given level in the context.
+// Records of unsampled traces below the unsampled level are never handled.
+func (h *Handler) nextEnabled(ctx context.Context, level slog.Level) bool {
+	if h.Next == nil || h.unsampledDropped(ctx, level) {
+		return false
+	}
+
+	return h.Next.Enabled(ctx, level) || h.contextEnabled(ctx, level)
+}
+
+// unsampledDropped reports whether a record of the given level is dropped because
+// the span of the context is not sampled.
+func (h *Handler) unsampledDropped(ctx context.Context, level slog.Level) bool {
+	if h.unsampledLevel == nil || level >= h.unsampledLevel.Level() {
+		return false
+	}
+
+	spanCtx := trace.SpanContextFromContext(ctx)
+	return spanCtx.IsValid() && !spanCtx.IsSampled()
+}
+
+// contextEnabled reports whether the context lowers the effective level to at most the given level,
+// either because its span is sampled or because its baggage names a level.
+func (h *Handler) contextEnabled(ctx context.Context, level slog.Level) bool {
+	if h.sampledLevel != nil && level >= h.sampledLevel.Level() && trace.SpanContextFromContext(ctx).IsSampled() {
+		return true
+	}
+
+	if h.baggageLevelKey != "" {
+		value := baggage.FromContext(ctx).Member(h.baggageLevelKey).Value()
+		var baggageLevel slog.Level
+		if value != "" && baggageLevel.UnmarshalText([]byte(value)) == nil && level >= baggageLevel {
+			return true
+		}
+	}
+
+	return false
+}
+
 // handleSpan handles the span for the slog record.
-// It returns nil if the span is not recording.
-// Otherwise, it adds span events and trace IDs to the span.
-func (h *Handler) handleSpan(ctx context.Context, record *slog.Record) error {
+// It returns true if the span is not recording, after adding the trace IDs of SpanContexts with local IDs
+// and of spans started by a SpanContext that have ended since.
+// Otherwise, it adds span events and trace IDs to the span, and reports whether
+// the record should be passed to the next handler.
+func (h *Handler) handleSpan(ctx context.Context, record *slog.Record) (bool, error) {
 	span := trace.SpanFromContext(ctx)
+	state := spanStateFromContext(ctx, span)
+	if state != nil {
+		span = state.span
+	}
+
 	if span == nil || !span.IsRecording() {
-		return nil
+		if state != nil && (state.local || state.recording) {
+			h.addTraceIDs(span, state, record)
+		}
+		return true, nil
 	}
 
-	if h.spanEvent {
-		h.addSpanEvents(span, record)
+	forward := true
+	if h.spanEvent && h.eventEnabled(state, record.Level) {
+		if h.events != nil {
+			forward = h.addSpanEventsAsync(span, state, record)
+		} else {
+			forward = h.addSpanEvents(span, state, record)
+		}
 	}
 
-	h.addTraceIDs(span, record)
-	h.setSpanStatus(span, record)
+	h.addTraceIDs(span, state, record)
+	h.setSpanStatus(span, state, record)
 
-	return nil
+	return forward, nil
+}
+
+// eventEnabled reports whether a record of the given level is recorded as a span event.
+// state is the state of the span if it was started by a SpanContext, or nil if it was started elsewhere.
+// The event level of the SpanContext takes precedence over the handler's event level.
+func (h *Handler) eventEnabled(state *spanState, level slog.Level) bool {
+	eventLevel := h.eventLevel
+	if state != nil && state.owner.eventLevel != nil {
+		eventLevel = state.owner.eventLevel
+	}
+
+	return eventLevel == nil || level >= eventLevel.Level()
 }
 
 // addSpanEvents adds span events to the span.
-// It collects the event attributes from the record and adds them to the span as an event.
-func (h *Handler) addSpanEvents(span trace.Span, record *slog.Record) {
-	eventAttrs := h.collectEventAttributes(record)
-	span.AddEvent(h.spanEventKey, trace.WithAttributes(eventAttrs...))
+// It collects the event attributes from the record and adds them to the span as an event
+// timestamped with the record time, subject to the repeat collapsing and event budget of the span state.
+// It reports false if the record repeats an earlier event and should not be passed to the next handler.
+func (h *Handler) addSpanEvents(span trace.Span, state *spanState, record *slog.Record) bool {
+	var repeats *spanRepeats
+	var budget *spanBudget
+	if state != nil {
+		repeats, budget = state.repeats, state.budget
+	}
+
+	name := h.eventName(record)
+	buf := getEventAttrs(len(h.attrs) + record.NumAttrs() + 4) // +4 for message, level, time, severity
+	eventAttrs := h.collectEventAttributes(*buf, record)
+
+	var key repeatKey
+	if repeats != nil {
+		key = newRepeatKey(name, record.Message, eventAttrs)
+		if repeats.repeat(key, record.Time) {
+			putEventAttrs(buf, eventAttrs)
+			return !h.suppressRepeats
+		}
+	}
+
+	if budget != nil && !budget.allow(record.Level) {
+		h.countSuppressedEvent()
+		putEventAttrs(buf, eventAttrs)
+		return true
+	}
+
+	eventAttrs = h.appendRecordFields(eventAttrs, record)
+	// A held event keeps its attributes for the repeat summary, so the buffer is not reused.
+	held := repeats != nil && repeats.hold(key, name, eventAttrs, record.Time)
+
+	opts := []trace.EventOption{trace.WithAttributes(eventAttrs...)}
+	if !record.Time.IsZero() {
+		opts = append(opts, trace.WithTimestamp(record.Time))
+	}
+	span.AddEvent(name, opts...)
+	if !held && copiesEventAttrs(span) {
+		putEventAttrs(buf, eventAttrs)
+	}
+	return true
 }
 
-// collectEventAttributes collects the event attributes from the record.
-// It collects the slog attributes from the record and the handler's group keys.
+// eventName returns the span event name for the record.
+// It falls back to the span event key if no namer is set or the namer returns an empty name.
+func (h *Handler) eventName(record *slog.Record) string {
+	if h.eventNamer != nil {
+		if name := h.eventNamer(*record); name != "" {
+			return name
+		}
+	}
+	return h.spanEventKey
+}
+
+// collectEventAttributes collects the event attributes from the record into eventAttrs.
+// It collects the handler's converted attributes and the slog attributes from the record with the handler's
+// group prefix.
 // It returns the collected attributes.
-func (h *Handler) collectEventAttributes(record *slog.Record) []attribute.KeyValue {
-	eventAttrs := make([]attribute.KeyValue, 0, record.NumAttrs()+3) // +3 for message, level, time
+func (h *Handler) collectEventAttributes(eventAttrs []attribute.KeyValue, record *slog.Record) []attribute.KeyValue {
+	appendAttr := func(kv attribute.KeyValue) {
+		if kv == (attribute.KeyValue{}) {
+			h.recordDroppedAttribute(dropReasonEmpty)
+			return
+		}
+		eventAttrs = append(eventAttrs, kv)
+	}
 
+	eventAttrs = append(eventAttrs, h.attrs...)
 	record.Attrs(func(attr slog.Attr) bool {
-		convertAttrs(attr, func(kv attribute.KeyValue) {
-			if kv != (attribute.KeyValue{}) {
-				eventAttrs = append(eventAttrs, kv)
-			}
-		}, h.groupKeys...)
+		h.convertAttrsPrefix(attr, appendAttr, h.groupPrefix)
 		return true
 	})
 
+	return eventAttrs
+}
+
+// appendRecordFields appends the record fields selected by the handler's event attrs.
+func (h *Handler) appendRecordFields(eventAttrs []attribute.KeyValue, record *slog.Record) []attribute.KeyValue {
 	// 添加基础属性
-	eventAttrs = append(eventAttrs,
-		attribute.String(slog.MessageKey, record.Message),
-		attribute.String(slog.LevelKey, record.Level.String()),
-		attribute.String(slog.TimeKey, record.Time.Format(time.RFC3339)))
+	if h.eventAttrs&EventMessage != 0 && h.eventMessageKey != "" {
+		eventAttrs = append(eventAttrs, attribute.String(h.eventMessageKey, record.Message))
+	}
+	if h.eventAttrs&EventLevel != 0 && h.eventLevelKey != "" {
+		eventAttrs = append(eventAttrs, attribute.String(h.eventLevelKey, record.Level.String()))
+	}
+	if h.eventAttrs&EventTime != 0 && h.eventTimeKey != "" && !record.Time.IsZero() {
+		eventAttrs = append(eventAttrs, attribute.String(h.eventTimeKey, record.Time.Format(time.RFC3339Nano)))
+	}
+	if h.eventAttrs&EventSeverity != 0 && h.eventSeverityKey != "" {
+		eventAttrs = append(eventAttrs, attribute.Int(h.eventSeverityKey, severityNumber(record.Level)))
+	}
 
 	return eventAttrs
 }
 
+// severityNumber maps a slog.Level to an OpenTelemetry severity number.
+// DEBUG, INFO, WARN and ERROR map to 5, 9, 13 and 17, clamped to the range 1 to 24.
+func severityNumber(level slog.Level) int {
+	return min(max(int(level)+9, 1), 24)
+}
+
 // addTraceIDs adds the trace IDs to the record.
-// It adds the trace ID and span ID to the record as slog attributes.
-func (h *Handler) addTraceIDs(span trace.Span, record *slog.Record) {
+// It adds the trace ID, span ID, trace flags, parent span ID and trace state to the record as slog attributes,
+// skipping fields whose key is empty. The parent span ID is only known for spans started by the handler.
+func (h *Handler) addTraceIDs(span trace.Span, state *spanState, record *slog.Record) {
 	spanCtx := span.SpanContext()
-	if spanCtx.HasTraceID() {
-		record.AddAttrs(slog.String(h.traceIDKey, spanCtx.TraceID().String()))
+	if h.traceIDFormatter != nil {
+		record.AddAttrs(h.traceIDFormatter(spanCtx)...)
+	} else {
+		if spanCtx.HasTraceID() && h.traceIDKey != "" {
+			record.AddAttrs(slog.String(h.traceIDKey, spanCtx.TraceID().String()))
+		}
+		if spanCtx.HasSpanID() && h.spanIDKey != "" {
+			record.AddAttrs(slog.String(h.spanIDKey, spanCtx.SpanID().String()))
+		}
+	}
+	if h.traceFlagsKey != "" {
+		record.AddAttrs(slog.String(h.traceFlagsKey, spanCtx.TraceFlags().String()))
+	}
+	if state != nil && state.parent.HasSpanID() && h.parentSpanIDKey != "" {
+		record.AddAttrs(slog.String(h.parentSpanIDKey, state.parent.SpanID().String()))
 	}
-	if spanCtx.HasSpanID() {
-		record.AddAttrs(slog.String(h.spanIDKey, spanCtx.SpanID().String()))
+	if traceState := spanCtx.TraceState().String(); traceState != "" && h.traceStateKey != "" {
+		record.AddAttrs(slog.String(h.traceStateKey, traceState))
 	}
 }
 
 // setSpanStatus sets the span status based on the record level.
-// It sets the span status to error if the record level is error.
-func (h *Handler) setSpanStatus(span trace.Span, record *slog.Record) {
+// It sets the span status to error if the record level is error, and marks the span as failed
+// if it was started by a SpanContext.
+func (h *Handler) setSpanStatus(span trace.Span, state *spanState, record *slog.Record) {
 	if record.Level == slog.LevelError {
 		span.SetStatus(codes.Error, record.Message)
-	}
-}
-
-// convertAttrs converts slog.Attrs to OpenTelemetry attributes.
-// It handles group keys by prefixing the attribute key with the group keys.
-func convertAttrs(attr slog.Attr, handler func(attribute.KeyValue), groupKeys ...string) {
-	key := attr.Key
-	if len(groupKeys) > 0 {
-		key = strings.Join(groupKeys, ".") + "." + attr.Key
-	}
-
-	val := attr.Value.Resolve()
-
-	switch val.Kind() {
-	case slog.KindBool:
-		handler(attribute.Bool(key, val.Bool()))
-	case slog.KindDuration:
-		handler(attribute.Int64(key, int64(val.Duration())))
-	case slog.KindFloat64:
-		handler(attribute.Float64(key, val.Float64()))
-	case slog.KindInt64:
-		handler(attribute.Int64(key, val.Int64()))
-	case slog.KindString:
-		handler(attribute.String(key, val.String()))
-	case slog.KindTime:
-		handler(attribute.String(key, val.Time().Format(time.RFC3339)))
-	// case slog.KindUint64: // attribute.KeyValue does not support Uint64
-	// 	handler(attribute.Uint64(key, val.Uint64()))
-	case slog.KindGroup:
-		for _, groupAttr := range val.Group() {
-			convertAttrs(groupAttr, handler, key)
+		if state != nil {
+			state.failed.Store(true)
 		}
-	case slog.KindAny:
-		handler(convertAnyValue(key, val.Any()))
-	default:
-		handler(attribute.String(key, fmt.Sprintf("%+v", val.Any())))
 	}
 }
 
-// convertAnyValue converts slog.Any to OpenTelemetry attributes.
-// It handles different types of values and returns the appropriate attribute.KeyValue.
-func convertAnyValue(key string, value any) attribute.KeyValue {
-	switch v := value.(type) {
-	case []string:
-		return attribute.StringSlice(key, v)
-	case []int:
-		return attribute.IntSlice(key, v)
-	case []int64:
-		return attribute.Int64Slice(key, v)
-	case []float64:
-		return attribute.Float64Slice(key, v)
-	case []bool:
-		return attribute.BoolSlice(key, v)
-	default:
-		return attribute.String(key, fmt.Sprintf("%+v", v))
-	}
-}
+// spanContextKey is the context key under which a SpanContext returns itself.
+type spanContextKey struct{}
 
 // SpanContext is a wrapper around trace.Span that provides a context.Context.
 // It contains the span, context, trace name, span name, and a flag to ensure the span is created.
+// Once started, every record logged with the SpanContext is attached to the same span until it ends.
 type SpanContext struct {
 	trace.Span
 	context.Context
-	traceName string
-	spanName  string
-	must      bool
+	traceName  string
+	spanName   string
+	must       bool
+	eventLevel slog.Leveler
+	base       context.Context
+	ended      bool
+	state      *spanState
+	endHooks   []func(trace.Span)
+	mu         sync.Mutex
+}
+
+// spanState is a span started by a SpanContext, together with the state kept for it.
+// It is created under the lock of the SpanContext and its fields are not reassigned afterwards, so a record
+// keeps using the span it was attached to while the SpanContext is ended and restarted concurrently.
+// As a context.Context, it carries the span and returns its SpanContext for the SpanContext key.
+// recording keeps whether the span was recorded when it started, so records racing with End still get its IDs.
+type spanState struct {
+	context.Context
+	owner     *SpanContext
+	span      trace.Span
+	parent    trace.SpanContext
+	recording bool
+	local     bool
+	failed    atomic.Bool
+	budget    *spanBudget
+	repeats   *spanRepeats
+	pending   *sync.WaitGroup
+}
+
+// Value returns the SpanContext that started the span for the SpanContext key,
+// and otherwise delegates to the context of the span.
+func (s *spanState) Value(key any) any {
+	if key == (spanContextKey{}) {
+		return s.owner
+	}
+	return s.Context.Value(key)
+}
+
+// spanStateFromContext returns the state of the span of the context if it was started by a SpanContext.
+func spanStateFromContext(ctx context.Context, span trace.Span) *spanState {
+	if state, ok := ctx.(*spanState); ok {
+		return state
+	}
+
+	spanCtx := spanContextFromContext(ctx)
+	if spanCtx == nil {
+		return nil
+	}
+	state := spanCtx.current()
+	// Spans are matched by their span context, as span implementations are not necessarily comparable.
+	if state == nil || !state.span.SpanContext().Equal(span.SpanContext()) {
+		return nil
+	}
+	return state
+}
+
+// spanContextFromContext returns the innermost SpanContext of the context, or nil if there is none.
+func spanContextFromContext(ctx context.Context) *SpanContext {
+	if ctx == nil {
+		return nil
+	}
+	spanCtx, _ := ctx.Value(spanContextKey{}).(*SpanContext)
+	return spanCtx
 }
 
 // NewSpanContext creates a new SpanContext with the given span name.
@@ -412,15 +1062,65 @@ func NewMustSpanContextWithContext(ctx context.Context, spanName string, traceNa
 	}
 }
 
+// WithEventLevel overrides the handler's event level for this span.
+// Records below the level are not recorded as events on the span. It returns the SpanContext.
+func (s *SpanContext) WithEventLevel(level slog.Leveler) *SpanContext {
+	s.eventLevel = level
+	return s
+}
+
+// Value returns the SpanContext itself for the SpanContext key, and otherwise delegates to the wrapped context.
+func (s *SpanContext) Value(key any) any {
+	if key == (spanContextKey{}) {
+		return s
+	}
+	ctx := s.wrapped()
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Value(key)
+}
+
+// wrapped returns the wrapped context, which is replaced whenever a span is started.
+func (s *SpanContext) wrapped() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Context
+}
+
+// current returns the state of the last span started by the SpanContext, or nil if none was started.
+func (s *SpanContext) current() *spanState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
 // End ends the span.
+// It first records any pending events, such as the event budget summary, on the span.
+// A record logged with the SpanContext after End starts a new span.
 func (s *SpanContext) End() {
-	if s.Span != nil {
-		s.Span.End()
+	s.mu.Lock()
+	span, hooks := s.Span, s.endHooks
+	s.endHooks = nil
+	if span != nil {
+		s.ended = true
+	}
+	s.mu.Unlock()
+
+	if span != nil {
+		for _, hook := range hooks {
+			hook(span)
+		}
+		span.End()
 	}
 }
 
 // Done ends the span and returns the context's done channel.
 func (s *SpanContext) Done() <-chan struct{} {
 	s.End()
-	return s.Context.Done()
+	ctx := s.wrapped()
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Done()
 }
@@ -8,16 +8,19 @@ package otelslog
 import (
 	"bytes"
 	"context"
-	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 // TestHandler tests the Handler implementation.
@@ -62,6 +65,21 @@ func TestHandler(t *testing.T) {
 		assert.Contains(t, buf.String(), `"key1":"value1"`)
 	})
 
+	t.Run("without NewHandler", func(t *testing.T) {
+		spanRecorder := setupTracer()
+		buf := bytes.NewBuffer(nil)
+		logger := slog.New(&Handler{Next: slog.NewJSONHandler(buf, nil)})
+
+		span := NewSpanContext("span", "trace")
+		assert.NotPanics(t, func() {
+			logger.Info("without NewHandler test", "operation", span)
+		})
+		span.End()
+
+		assert.Contains(t, buf.String(), `"msg":"without NewHandler test"`)
+		assert.Equal(t, 1, len(spanRecorder.Ended()))
+	})
+
 	t.Run("with span events", func(t *testing.T) {
 		spanRecorder := setupTracer()
 		buf := setupLogger()
@@ -80,6 +98,132 @@ func TestHandler(t *testing.T) {
 		assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String("key1", "value1"))
 	})
 
+	t.Run("with span event timestamp", func(t *testing.T) {
+		spanRecorder := setupTracer()
+		_ = setupLogger()
+
+		span := NewSpanContext("span", "trace")
+		ts := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+		record := slog.NewRecord(ts, slog.LevelInfo, "with span event timestamp", 0)
+		record.AddAttrs(slog.Any("operation", span))
+		assert.NoError(t, slog.Default().Handler().Handle(context.Background(), record))
+		span.End()
+
+		spans := spanRecorder.Ended()
+
+		assert.Equal(t, 1, len(spans))
+		assert.Equal(t, ts, spans[0].Events()[0].Time)
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String(slog.MessageKey, "with span event timestamp"),
+			attribute.String(slog.LevelKey, "INFO"),
+		}, spans[0].Events()[0].Attributes)
+	})
+
+	t.Run("with span event attrs", func(t *testing.T) {
+		spanRecorder := setupTracer()
+		_ = setupLogger(WithEventAttrs(EventTime | EventSeverity))
+
+		span := NewSpanContext("span", "trace")
+		ts := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+		record := slog.NewRecord(ts, slog.LevelWarn, "with span event attrs", 0)
+		record.AddAttrs(slog.Any("operation", span))
+		assert.NoError(t, slog.Default().Handler().Handle(context.Background(), record))
+		span.End()
+
+		spans := spanRecorder.Ended()
+
+		assert.Equal(t, 1, len(spans))
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String(slog.TimeKey, "2024-01-02T03:04:05.000000006Z"),
+			attribute.Int(SeverityNumberKey, 13),
+		}, spans[0].Events()[0].Attributes)
+	})
+
+	t.Run("with span event namer", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			namer    EventNamer
+			expected []string
+		}{
+			{name: "default", namer: nil, expected: []string{"log", "log"}},
+			{name: "message", namer: EventNameMessage(), expected: []string{"first", "second"}},
+			{name: "level", namer: EventNameLevel(), expected: []string{"INFO", "WARN"}},
+			{name: "attr", namer: EventNameAttr("event"), expected: []string{"created", "log"}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				spanRecorder := setupTracer()
+				_ = setupLogger(WithEventNamer(test.namer))
+
+				ctx, span := otel.Tracer("trace").Start(context.Background(), "span")
+				slog.InfoContext(ctx, "first", "event", "created")
+				slog.WarnContext(ctx, "second")
+				span.End()
+
+				spans := spanRecorder.Ended()
+
+				assert.Equal(t, 1, len(spans))
+				names := make([]string, 0)
+				for _, event := range spans[0].Events() {
+					names = append(names, event.Name)
+				}
+				assert.Equal(t, test.expected, names)
+				assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String(slog.MessageKey, "first"))
+			})
+		}
+	})
+
+	t.Run("with span context reused", func(t *testing.T) {
+		spanRecorder := setupTracer()
+		_ = setupLogger()
+
+		spanCtx := NewSpanContext("span", "trace")
+		slog.InfoContext(spanCtx, "first")
+		slog.InfoContext(spanCtx, "second")
+		slog.Info("third", "operation", spanCtx)
+		spanCtx.End()
+
+		spans := spanRecorder.Ended()
+
+		assert.Equal(t, 1, len(spans))
+		assert.Equal(t, 3, len(spans[0].Events()))
+	})
+
+	t.Run("with event level", func(t *testing.T) {
+		spanRecorder := setupTracer()
+		buf := setupLogger(WithTraceLevel(slog.LevelDebug), WithEventLevel(slog.LevelInfo))
+
+		spanCtx := NewSpanContext("span", "trace")
+		slog.DebugContext(spanCtx, "debug")
+		slog.InfoContext(spanCtx, "info")
+		spanCtx.End()
+
+		assert.Contains(t, buf.String(), `"msg":"info"`)
+
+		spans := spanRecorder.Ended()
+
+		assert.Equal(t, 1, len(spans))
+		assert.Equal(t, 1, len(spans[0].Events()))
+		assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String(slog.MessageKey, "info"))
+	})
+
+	t.Run("with span event level", func(t *testing.T) {
+		spanRecorder := setupTracer()
+		_ = setupLogger(WithEventLevel(slog.LevelInfo))
+
+		spanCtx := NewSpanContext("span", "trace").WithEventLevel(slog.LevelWarn)
+		slog.InfoContext(spanCtx, "info")
+		slog.WarnContext(spanCtx, "warn")
+		spanCtx.End()
+
+		spans := spanRecorder.Ended()
+
+		assert.Equal(t, 1, len(spans))
+		assert.Equal(t, 1, len(spans[0].Events()))
+		assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String(slog.MessageKey, "warn"))
+	})
+
 	t.Run("with span no events", func(t *testing.T) {
 		spanRecorder := setupTracer()
 		buf := setupLogger(WithNoSpanEvents())
@@ -117,6 +261,108 @@ func TestHandler(t *testing.T) {
 		assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String("key1", "value1"))
 	})
 
+	t.Run("with attrs on slog.With", func(t *testing.T) {
+		spanRecorder := setupTracer()
+		buf := setupLogger()
+
+		span := NewSpanContext("span", "trace")
+		slog.With("key1", "value1").WithGroup("group").With("operation", span, "key2", "value2").
+			Info("with attrs on slog.With", "key3", "value3")
+		slog.With("key4", "value4").Info("without span")
+		span.End()
+
+		assert.Contains(t, buf.String(), `"key1":"value1","group":{"key2":"value2","key3":"value3"`)
+		assert.Contains(t, buf.String(), `"msg":"without span","key4":"value4"`)
+		assert.NotContains(t, buf.String(), `"operation"`)
+
+		spans := spanRecorder.Ended()
+
+		assert.Equal(t, 1, len(spans))
+		assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String("key1", "value1"))
+		assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String("group.key2", "value2"))
+		assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String("group.key3", "value3"))
+	})
+
+	t.Run("with root trace fields", func(t *testing.T) {
+		_ = setupTracer()
+		buf := setupLogger(WithRootTraceFields())
+
+		span := NewSpanContext("span", "trace")
+		slog.With("key1", "value1").WithGroup("group1").With("key2", "value2").WithGroup("group2").
+			InfoContext(span, "with root trace fields", "key3", "value3")
+		span.End()
+
+		assert.Contains(t, buf.String(), `"key1":"value1","group1":{"key2":"value2","group2":{"key3":"value3"}},"trace_id":"`)
+	})
+
+	t.Run("with trace fields group", func(t *testing.T) {
+		_ = setupTracer()
+		buf := setupLogger(WithTraceFieldsGroup("trace"))
+
+		span := NewSpanContext("span", "trace")
+		slog.Default().WithGroup("request").InfoContext(span, "with trace fields group", "key1", "value1")
+		slog.Default().WithGroup("request").Info("without span", "key2", "value2")
+		span.End()
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, 2, len(lines))
+		assert.Contains(t, lines[0], `"request":{"key1":"value1"},"trace":{"trace_id":"`)
+		assert.Contains(t, lines[1], `"request":{"key2":"value2"}}`)
+	})
+
+	t.Run("with span after end", func(t *testing.T) {
+		spanRecorder := setupTracer()
+		buf := setupLogger()
+
+		span := NewSpanContext("span", "trace")
+		slog.InfoContext(span, "before end")
+		span.End()
+		slog.InfoContext(span, "after end")
+		span.End()
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, 2, len(lines))
+		assert.Contains(t, lines[0], `"span_id":"`)
+		assert.Contains(t, lines[1], `"msg":"after end","trace_id":"`)
+
+		spans := spanRecorder.Ended()
+
+		assert.Equal(t, 2, len(spans))
+		assert.NotEqual(t, spans[0].SpanContext().SpanID(), spans[1].SpanContext().SpanID())
+		assert.Contains(t, spans[1].Events()[0].Attributes, attribute.String(slog.MessageKey, "after end"))
+	})
+
+	t.Run("with span logged concurrently with end", func(t *testing.T) {
+		setupTracer()
+		buf := setupLogger(WithCollapseRepeats(16), WithEventBudget(EventBudget{MaxEvents: 1000}))
+
+		span := NewSpanContext("span", "trace")
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 1; j <= 200; j++ {
+					if j%2 == 0 {
+						slog.InfoContext(span, "concurrent", "j", j)
+					} else {
+						slog.Info("concurrent", "operation", span, "j", j)
+					}
+					if j%50 == 0 {
+						span.End()
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, 8*200, len(lines))
+		for _, line := range lines {
+			assert.Contains(t, line, `"trace_id":"`)
+		}
+	})
+
 	t.Run("with span on slog.WithGroup", func(t *testing.T) {
 		spanRecorder := setupTracer()
 		buf := setupLogger()
@@ -155,6 +401,26 @@ func TestHandler(t *testing.T) {
 		assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String("group1.group2.key1", "value1"))
 	})
 
+	t.Run("with sibling groups", func(t *testing.T) {
+		spanRecorder := setupTracer()
+		_ = setupLogger()
+
+		parent := slog.Default().WithGroup("a").With("key", "value").WithGroup("b").WithGroup("c")
+		x, y := parent.WithGroup("x"), parent.WithGroup("y")
+
+		span := NewSpanContext("span", "trace")
+		x.InfoContext(span, "x", "key1", "value1")
+		y.InfoContext(span, "y", "key2", "value2")
+		span.End()
+
+		spans := spanRecorder.Ended()
+		if assert.Equal(t, 1, len(spans)) && assert.Equal(t, 2, len(spans[0].Events())) {
+			assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String("a.key", "value"))
+			assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String("a.b.c.x.key1", "value1"))
+			assert.Contains(t, spans[0].Events()[1].Attributes, attribute.String("a.b.c.y.key2", "value2"))
+		}
+	})
+
 	t.Run("with span on slog.Group", func(t *testing.T) {
 		spanRecorder := setupTracer()
 		buf := setupLogger()
@@ -234,6 +500,137 @@ func TestHandler(t *testing.T) {
 		assert.Equal(t, 0, len(spans))
 	})
 
+	t.Run("with dynamic trace level", func(t *testing.T) {
+		spanRecorder := setupTracer()
+		traceLevel, eventLevel := new(slog.LevelVar), new(slog.LevelVar)
+		traceLevel.Set(slog.LevelWarn)
+		eventLevel.Set(slog.LevelWarn)
+		_ = setupLogger(WithTraceLevel(traceLevel), WithEventLevel(eventLevel))
+
+		span1 := NewSpanContext("span1", "trace")
+		slog.InfoContext(span1, "not traced")
+		span1.End()
+
+		assert.Equal(t, 0, len(spanRecorder.Ended()))
+
+		traceLevel.Set(slog.LevelDebug)
+		eventLevel.Set(slog.LevelInfo)
+
+		span2 := NewSpanContext("span2", "trace")
+		slog.DebugContext(span2, "traced")
+		slog.InfoContext(span2, "recorded")
+		span2.End()
+
+		spans := spanRecorder.Ended()
+
+		assert.Equal(t, 1, len(spans))
+		assert.Equal(t, "span2", spans[0].Name())
+		assert.Equal(t, 1, len(spans[0].Events()))
+		assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String(slog.MessageKey, "recorded"))
+	})
+
+	t.Run("with sampled level", func(t *testing.T) {
+		_ = setupTracer()
+		buf := bytes.NewBuffer(nil)
+		logger := slog.New(NewHandler(
+			slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}),
+			WithSampledLevel(slog.LevelDebug)))
+
+		logger.Debug("not sampled")
+
+		ctx, span := otel.Tracer("trace").Start(context.Background(), "span")
+		logger.DebugContext(ctx, "sampled")
+		span.End()
+
+		assert.NotContains(t, buf.String(), `"msg":"not sampled"`)
+		assert.Contains(t, buf.String(), `"msg":"sampled"`)
+	})
+
+	t.Run("with baggage level", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		logger := slog.New(NewHandler(
+			slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}),
+			WithBaggageLevel("log.level")))
+
+		member, err := baggage.NewMember("log.level", "debug")
+		assert.NoError(t, err)
+		bag, err := baggage.New(member)
+		assert.NoError(t, err)
+		ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+		logger.Debug("without baggage")
+		logger.DebugContext(ctx, "with baggage")
+
+		assert.NotContains(t, buf.String(), `"msg":"without baggage"`)
+		assert.Contains(t, buf.String(), `"msg":"with baggage"`)
+	})
+
+	t.Run("with unsampled level", func(t *testing.T) {
+		otel.SetTracerProvider(trace.NewTracerProvider(trace.WithSampler(trace.NeverSample())))
+		buf := setupLogger(WithUnsampledLevel(slog.LevelWarn))
+
+		spanCtx := NewSpanContext("span", "trace")
+		slog.InfoContext(spanCtx, "unsampled info")
+		slog.WarnContext(spanCtx, "unsampled warn")
+		slog.Info("no span info")
+		spanCtx.End()
+
+		assert.NotContains(t, buf.String(), `"msg":"unsampled info"`)
+		assert.Contains(t, buf.String(), `"msg":"unsampled warn"`)
+		assert.Contains(t, buf.String(), `"msg":"no span info"`)
+
+		_ = setupTracer()
+		spanCtx = NewSpanContext("span", "trace")
+		slog.InfoContext(spanCtx, "sampled info")
+		spanCtx.End()
+
+		assert.Contains(t, buf.String(), `"msg":"sampled info"`)
+	})
+
+	t.Run("with trace fields", func(t *testing.T) {
+		_ = setupTracer()
+		buf := setupLogger()
+
+		parent := NewSpanContext("parent", "trace")
+		slog.InfoContext(parent, "parent")
+		child := NewSpanContextWithContext(parent, "child", "trace")
+		slog.InfoContext(child, "child")
+		child.End()
+		parent.End()
+
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		assert.Equal(t, 2, len(lines))
+		assert.Contains(t, string(lines[0]), `"trace_flags":"01"`)
+		assert.NotContains(t, string(lines[0]), `"parent_span_id"`)
+		assert.Contains(t, string(lines[1]), `"parent_span_id":"`+parent.SpanContext().SpanID().String()+`"`)
+		assert.NotContains(t, buf.String(), `"trace_state"`)
+	})
+
+	t.Run("with trace field keys", func(t *testing.T) {
+		_ = setupTracer()
+		buf := setupLogger(WithTraceIDKey(""), WithTraceFlagsKey("flags"), WithParentSpanIDKey(""), WithTraceStateKey("trace_state"))
+
+		state, err := oteltrace.ParseTraceState("vendor=value")
+		assert.NoError(t, err)
+		parentCtx := oteltrace.ContextWithRemoteSpanContext(context.Background(), oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+			TraceID:    oteltrace.TraceID{1},
+			SpanID:     oteltrace.SpanID{1},
+			TraceFlags: oteltrace.FlagsSampled,
+			TraceState: state,
+			Remote:     true,
+		}))
+
+		spanCtx := NewSpanContextWithContext(parentCtx, "span", "trace")
+		slog.InfoContext(spanCtx, "with trace field keys")
+		spanCtx.End()
+
+		assert.NotContains(t, buf.String(), `"trace_id"`)
+		assert.NotContains(t, buf.String(), `"parent_span_id"`)
+		assert.Contains(t, buf.String(), `"span_id"`)
+		assert.Contains(t, buf.String(), `"flags":"01"`)
+		assert.Contains(t, buf.String(), `"trace_state":"vendor=value"`)
+	})
+
 	t.Run("with must span", func(t *testing.T) {
 		spanRecorder := setupTracer()
 		buf := setupLogger(WithTraceLevel(slog.LevelWarn))
@@ -319,170 +716,3 @@ func TestHandler(t *testing.T) {
 		assert.Equal(t, spans[0].SpanContext().TraceID(), spans[1].SpanContext().TraceID())
 	})
 }
-
-func TestConvertAttrs(t *testing.T) {
-	tests := []struct {
-		name     string
-		attr     slog.Attr
-		expected attribute.KeyValue
-	}{
-		{
-			name:     "string",
-			attr:     slog.String("key1", "value1"),
-			expected: attribute.String("log.key1", "value1"),
-		},
-		{
-			name:     "int64",
-			attr:     slog.Int64("key2", 42),
-			expected: attribute.Int64("log.key2", 42),
-		},
-		{
-			name:     "bool",
-			attr:     slog.Bool("key3", true),
-			expected: attribute.Bool("log.key3", true),
-		},
-		{
-			name:     "duration",
-			attr:     slog.Duration("key4", 5*time.Second),
-			expected: attribute.Int64("log.key4", 5000000000), // 5 seconds in nanoseconds
-		},
-		{
-			name:     "float64",
-			attr:     slog.Float64("key5", 3.14),
-			expected: attribute.Float64("log.key5", 3.14),
-		},
-		{
-			name:     "time",
-			attr:     slog.Time("key6", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)),
-			expected: attribute.String("log.key6", "2023-01-01T00:00:00Z"),
-		},
-		{
-			name:     "any",
-			attr:     slog.Any("key7", []string{"value1", "value2"}),
-			expected: attribute.StringSlice("log.key7", []string{"value1", "value2"}),
-		},
-		{
-			name:     "uint64",
-			attr:     slog.Uint64("key8", 100),
-			expected: attribute.String("log.key8", "100"), // attribute.KeyValue does not support Uint64, so we use String
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			result := make([]attribute.KeyValue, 0)
-			convertAttrs(test.attr, func(kv attribute.KeyValue) {
-				result = append(result, kv)
-			}, "log")
-			assert.Equal(t, test.expected, result[0])
-		})
-	}
-}
-
-func TestConvertAttrsWithGroup(t *testing.T) {
-	tests := []struct {
-		name     string
-		attr     slog.Attr
-		expected []attribute.KeyValue
-	}{
-		{
-			name:     "string and int64",
-			attr:     slog.Group("group", slog.String("key1", "value1"), slog.Int64("key2", 42)),
-			expected: []attribute.KeyValue{attribute.String("log.group.key1", "value1"), attribute.Int64("log.group.key2", 42)},
-		},
-		{
-			name:     "int64",
-			attr:     slog.Group("group", slog.Int64("key2", 42)),
-			expected: []attribute.KeyValue{attribute.Int64("log.group.key2", 42)},
-		},
-		{
-			name:     "bool",
-			attr:     slog.Group("group", slog.Bool("key3", true)),
-			expected: []attribute.KeyValue{attribute.Bool("log.group.key3", true)},
-		},
-		{
-			name:     "duration",
-			attr:     slog.Group("group", slog.Duration("key4", 5*time.Second)),
-			expected: []attribute.KeyValue{attribute.Int64("log.group.key4", 5000000000)}, // 5 seconds in nanoseconds
-		},
-		{
-			name:     "float64",
-			attr:     slog.Group("group", slog.Float64("key5", 3.14)),
-			expected: []attribute.KeyValue{attribute.Float64("log.group.key5", 3.14)},
-		},
-		{
-			name:     "time",
-			attr:     slog.Group("group", slog.Time("key6", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))),
-			expected: []attribute.KeyValue{attribute.String("log.group.key6", "2023-01-01T00:00:00Z")},
-		},
-		{
-			name:     "nested string",
-			attr:     slog.Group("group", slog.Group("subgroup", slog.String("key7", "value2"))),
-			expected: []attribute.KeyValue{attribute.String("log.group.subgroup.key7", "value2")},
-		},
-		{
-			name:     "nested int64",
-			attr:     slog.Group("group", slog.Group("subgroup", slog.Int64("key8", 100))),
-			expected: []attribute.KeyValue{attribute.Int64("log.group.subgroup.key8", 100)},
-		},
-		{
-			name:     "nested bool",
-			attr:     slog.Group("group", slog.Group("subgroup", slog.Bool("key9", false))),
-			expected: []attribute.KeyValue{attribute.Bool("log.group.subgroup.key9", false)},
-		},
-		{
-			name:     "nested duration",
-			attr:     slog.Group("group", slog.Group("subgroup", slog.Duration("key10", 10*time.Second))),
-			expected: []attribute.KeyValue{attribute.Int64("log.group.subgroup.key10", 10000000000)},
-		},
-		{
-			name:     "nested float64",
-			attr:     slog.Group("group", slog.Group("subgroup", slog.Float64("key11", 6.28))),
-			expected: []attribute.KeyValue{attribute.Float64("log.group.subgroup.key11", 6.28)},
-		},
-		{
-			name:     "nested time",
-			attr:     slog.Group("group", slog.Group("subgroup", slog.Time("key12", time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)))),
-			expected: []attribute.KeyValue{attribute.String("log.group.subgroup.key12", "2023-02-01T00:00:00Z")},
-		},
-		{
-			name: "any",
-			attr: slog.Group("group", slog.Any("key13",
-				[]string{"value1", "value2"}), slog.Any("key14",
-				[]int{1, 2}), slog.Any("key15", []int64{3, 4}),
-				slog.Any("key16", []float64{5.0, 6.0}),
-				slog.Any("key17", []bool{true, false}),
-				slog.Any("key18", struct {
-					Field1 string
-					Field2 int
-				}{
-					Field1: "value1",
-					Field2: 2,
-				})),
-			expected: []attribute.KeyValue{
-				attribute.StringSlice("log.group.key13", []string{"value1", "value2"}),
-				attribute.IntSlice("log.group.key14", []int{1, 2}),
-				attribute.Int64Slice("log.group.key15", []int64{3, 4}),
-				attribute.Float64Slice("log.group.key16", []float64{5.0, 6.0}),
-				attribute.BoolSlice("log.group.key17", []bool{true, false}),
-				attribute.String("log.group.key18", fmt.Sprintf("%+v", struct {
-					Field1 string
-					Field2 int
-				}{
-					Field1: "value1",
-					Field2: 2,
-				})),
-			},
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			result := make([]attribute.KeyValue, 0)
-			convertAttrs(test.attr, func(kv attribute.KeyValue) {
-				result = append(result, kv)
-			}, "log")
-			assert.Equal(t, test.expected, result)
-		})
-	}
-}
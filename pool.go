@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"slices"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// defaultEventAttrsCap is the initial capacity of pooled event attribute buffers.
+	defaultEventAttrsCap = 16
+	// maxPooledEventAttrsCap is the capacity above which event attribute buffers are not pooled,
+	// so that an occasional large record does not pin a large buffer.
+	maxPooledEventAttrsCap = 256
+)
+
+// eventAttrsPool pools the buffers that span event attributes are collected into.
+var eventAttrsPool = sync.Pool{
+	New: func() any {
+		buf := make([]attribute.KeyValue, 0, defaultEventAttrsCap)
+		return &buf
+	},
+}
+
+// getEventAttrs returns an empty pooled buffer with room for at least n attributes.
+func getEventAttrs(n int) *[]attribute.KeyValue {
+	buf := eventAttrsPool.Get().(*[]attribute.KeyValue)
+	*buf = slices.Grow((*buf)[:0], n)
+	return buf
+}
+
+// putEventAttrs returns the buffer to the pool, holding the attributes that were collected into it.
+// The attributes are cleared so that the pool does not keep their values alive.
+func putEventAttrs(buf *[]attribute.KeyValue, eventAttrs []attribute.KeyValue) {
+	if cap(eventAttrs) > maxPooledEventAttrsCap {
+		return
+	}
+
+	clear(eventAttrs)
+	*buf = eventAttrs[:0]
+	eventAttrsPool.Put(buf)
+}
+
+// copiesEventAttrs reports whether the span is known to copy the attributes of its events before AddEvent returns,
+// so that their buffer can be reused. Only spans of the OpenTelemetry SDK are known to do so;
+// other implementations may keep the event options and read them later.
+func copiesEventAttrs(span trace.Span) bool {
+	_, ok := span.(sdktrace.ReadWriteSpan)
+	return ok
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// retainingSpan is a recording span that keeps the options of its events and reads them later.
+type retainingSpan struct {
+	noop.Span
+	events [][]oteltrace.EventOption
+}
+
+func (s *retainingSpan) IsRecording() bool {
+	return true
+}
+
+func (s *retainingSpan) AddEvent(_ string, opts ...oteltrace.EventOption) {
+	s.events = append(s.events, opts)
+}
+
+func TestPutEventAttrs(t *testing.T) {
+	buf := getEventAttrs(2)
+	eventAttrs := append(*buf, attribute.String("key", "value"))
+	putEventAttrs(buf, eventAttrs)
+
+	assert.Empty(t, *buf)
+	assert.Equal(t, attribute.KeyValue{}, eventAttrs[0])
+
+	large := make([]attribute.KeyValue, 0, maxPooledEventAttrsCap+1)
+	large = append(large, attribute.String("key", "value"))
+	putEventAttrs(&large, large)
+	assert.Equal(t, attribute.String("key", "value"), large[0])
+}
+
+func TestEventAttrsPool(t *testing.T) {
+	t.Run("non-SDK span", func(t *testing.T) {
+		span := &retainingSpan{}
+		ctx := oteltrace.ContextWithSpan(context.Background(), span)
+		logger := slog.New(NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil)))
+
+		logger.InfoContext(ctx, "first", "key", "first")
+		logger.InfoContext(ctx, "second", "key", "second")
+
+		if assert.Len(t, span.events, 2) {
+			for i, expected := range []string{"first", "second"} {
+				config := oteltrace.NewEventConfig(span.events[i]...)
+				assert.Contains(t, config.Attributes(), attribute.String("key", expected))
+			}
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		spanRecorder := tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(trace.NewTracerProvider(trace.WithSpanProcessor(spanRecorder)))
+		logger := slog.New(NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil))).With("shared", "value")
+
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				span := NewSpanContext("span")
+				for j := 0; j < 50; j++ {
+					logger.InfoContext(span, "event", "goroutine", i, "j", j)
+				}
+				span.End()
+			}()
+		}
+		wg.Wait()
+
+		spans := spanRecorder.Ended()
+		assert.Len(t, spans, 16)
+		for _, span := range spans {
+			if !assert.Len(t, span.Events(), 50) {
+				continue
+			}
+			goroutine := span.Events()[0].Attributes[1]
+			assert.Equal(t, attribute.Key("goroutine"), goroutine.Key)
+			for j, event := range span.Events() {
+				assert.Contains(t, event.Attributes, attribute.String("shared", "value"))
+				assert.Contains(t, event.Attributes, goroutine)
+				assert.Contains(t, event.Attributes, attribute.Int64("j", int64(j)))
+			}
+		}
+	})
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import "log/slog"
+
+// FieldPreset is a set of field names for a log backend, applied with WithFieldPreset.
+// Every key is applied as is, so an empty key disables the field.
+type FieldPreset struct {
+	// Keys of the trace fields recorded in slog records.
+	TraceIDKey      string
+	SpanIDKey       string
+	TraceFlagsKey   string
+	ParentSpanIDKey string
+	TraceStateKey   string
+
+	// TraceIDFormatter, if set, replaces the trace ID and span ID keys.
+	TraceIDFormatter TraceIDFormatter
+
+	// Keys of the record fields recorded as span event attributes.
+	EventMessageKey  string
+	EventLevelKey    string
+	EventTimeKey     string
+	EventSeverityKey string
+
+	// EventAttrs, if not zero, selects the record fields recorded as span event attributes.
+	EventAttrs EventAttrs
+}
+
+// defaultFields are the field names used by NewHandler.
+var defaultFields = FieldPreset{
+	TraceIDKey:       "trace_id",
+	SpanIDKey:        "span_id",
+	TraceFlagsKey:    "trace_flags",
+	ParentSpanIDKey:  "parent_span_id",
+	EventMessageKey:  slog.MessageKey,
+	EventLevelKey:    slog.LevelKey,
+	EventTimeKey:     slog.TimeKey,
+	EventSeverityKey: SeverityNumberKey,
+	EventAttrs:       EventMessage | EventLevel,
+}
+
+// DefaultFields returns the field names used by NewHandler.
+func DefaultFields() FieldPreset {
+	return defaultFields
+}
+
+// ECS returns the field names of the Elastic Common Schema.
+func ECS() FieldPreset {
+	return FieldPreset{
+		TraceIDKey:       "trace.id",
+		SpanIDKey:        "span.id",
+		EventMessageKey:  "message",
+		EventLevelKey:    "log.level",
+		EventTimeKey:     "@timestamp",
+		EventSeverityKey: "event.severity",
+	}
+}
+
+// OTelLogDataModel returns the field names of the OpenTelemetry log data model.
+func OTelLogDataModel() FieldPreset {
+	return FieldPreset{
+		TraceIDKey:       "trace_id",
+		SpanIDKey:        "span_id",
+		TraceFlagsKey:    "trace_flags",
+		EventMessageKey:  "body",
+		EventLevelKey:    "severity_text",
+		EventTimeKey:     "timestamp",
+		EventSeverityKey: "severity_number",
+		EventAttrs:       EventMessage | EventLevel | EventSeverity,
+	}
+}
+
+// Datadog returns the field names of Datadog log management, with Datadog trace correlation.
+func Datadog() FieldPreset {
+	return FieldPreset{
+		TraceIDFormatter: DatadogTraceIDFormatter(),
+		EventMessageKey:  "message",
+		EventLevelKey:    "status",
+		EventTimeKey:     "date",
+		EventSeverityKey: "severity_number",
+	}
+}
+
+// GCPCloudLogging returns the field names of Google Cloud Logging, with trace correlation for the given project.
+func GCPCloudLogging(projectID string) FieldPreset {
+	return FieldPreset{
+		TraceIDFormatter: GCPTraceIDFormatter(projectID),
+		EventMessageKey:  "message",
+		EventLevelKey:    "severity",
+		EventTimeKey:     "timestamp",
+		EventSeverityKey: "severity_number",
+	}
+}
+
+// apply sets the field names of the preset on the handler.
+func (p FieldPreset) apply(h *Handler) {
+	h.traceIDKey = p.TraceIDKey
+	h.spanIDKey = p.SpanIDKey
+	h.traceFlagsKey = p.TraceFlagsKey
+	h.parentSpanIDKey = p.ParentSpanIDKey
+	h.traceStateKey = p.TraceStateKey
+	h.traceIDFormatter = p.TraceIDFormatter
+	h.eventMessageKey = p.EventMessageKey
+	h.eventLevelKey = p.EventLevelKey
+	h.eventTimeKey = p.EventTimeKey
+	h.eventSeverityKey = p.EventSeverityKey
+	if p.EventAttrs != 0 {
+		h.eventAttrs = p.EventAttrs
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHandlerFieldPreset(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Options
+		contains    []string
+		notContains []string
+		eventAttrs  []attribute.KeyValue
+	}{
+		{
+			name:       "default",
+			contains:   []string{`"trace_id":"`, `"span_id":"`, `"trace_flags":"01"`},
+			eventAttrs: []attribute.KeyValue{attribute.String("msg", "preset"), attribute.String("level", "INFO")},
+		},
+		{
+			name:        "ecs",
+			opts:        []Options{WithFieldPreset(ECS())},
+			contains:    []string{`"trace.id":"`, `"span.id":"`},
+			notContains: []string{`"trace_id"`, `"trace_flags"`},
+			eventAttrs:  []attribute.KeyValue{attribute.String("message", "preset"), attribute.String("log.level", "INFO")},
+		},
+		{
+			name:     "otel log data model",
+			opts:     []Options{WithFieldPreset(OTelLogDataModel())},
+			contains: []string{`"trace_id":"`, `"span_id":"`, `"trace_flags":"01"`},
+			eventAttrs: []attribute.KeyValue{
+				attribute.String("body", "preset"),
+				attribute.String("severity_text", "INFO"),
+				attribute.Int("severity_number", 9),
+			},
+		},
+		{
+			name:        "datadog",
+			opts:        []Options{WithFieldPreset(Datadog())},
+			contains:    []string{`"dd.trace_id":"`, `"dd.span_id":"`},
+			notContains: []string{`"trace_id"`, `"trace_flags"`},
+			eventAttrs:  []attribute.KeyValue{attribute.String("message", "preset"), attribute.String("status", "INFO")},
+		},
+		{
+			name:        "gcp cloud logging",
+			opts:        []Options{WithFieldPreset(GCPCloudLogging("project")), WithTraceStateKey("trace_state")},
+			contains:    []string{`"logging.googleapis.com/trace":"projects/project/traces/`, `"logging.googleapis.com/trace_sampled":true`},
+			notContains: []string{`"trace_id"`, `"trace_flags"`},
+			eventAttrs:  []attribute.KeyValue{attribute.String("message", "preset"), attribute.String("severity", "INFO")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spanRecorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(trace.NewTracerProvider(trace.WithSpanProcessor(spanRecorder)))
+			buf := bytes.NewBuffer(nil)
+			logger := slog.New(NewHandler(slog.NewJSONHandler(buf, nil), test.opts...))
+
+			spanCtx := NewSpanContext("span", "trace")
+			logger.InfoContext(spanCtx, "preset")
+			spanCtx.End()
+
+			for _, s := range test.contains {
+				assert.Contains(t, buf.String(), s)
+			}
+			for _, s := range test.notContains {
+				assert.NotContains(t, buf.String(), s)
+			}
+
+			spans := spanRecorder.Ended()
+
+			assert.Equal(t, 1, len(spans))
+			assert.Equal(t, test.eventAttrs, spans[0].Events()[0].Attributes)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"hash/fnv"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultMaxRepeatKeys is the number of distinct events tracked per span when collapsing repeats.
+const defaultMaxRepeatKeys = 128
+
+const (
+	// RepeatCountKey is the attribute key of the number of times a collapsed log event was repeated
+	// after its first occurrence.
+	RepeatCountKey = "repeat_count"
+	// FirstTimeKey is the attribute key of the RFC 3339 time a collapsed log event was first logged.
+	FirstTimeKey = "first_time"
+	// LastTimeKey is the attribute key of the RFC 3339 time a collapsed log event was last logged.
+	LastTimeKey = "last_time"
+)
+
+// repeatKey identifies identical log events within a span by name, message and a hash of the converted attributes.
+type repeatKey struct {
+	name    string
+	message string
+	hash    uint64
+}
+
+// newRepeatKey creates the repeat key of an event.
+func newRepeatKey(name, message string, attrs []attribute.KeyValue) repeatKey {
+	hash := fnv.New64a()
+	for _, kv := range attrs {
+		_, _ = hash.Write([]byte(kv.Key))
+		_, _ = hash.Write([]byte{0, byte(kv.Value.Type())})
+		_, _ = hash.Write([]byte(kv.Value.Emit()))
+		_, _ = hash.Write([]byte{0})
+	}
+
+	return repeatKey{name: name, message: message, hash: hash.Sum64()}
+}
+
+// repeatedEvent is a log event whose repeats are counted until its span ends.
+type repeatedEvent struct {
+	name  string
+	attrs []attribute.KeyValue
+	first time.Time
+	last  time.Time
+	count int64
+}
+
+// spanRepeats collapses repeated log events of a single span.
+// At most maxKeys distinct events are held; further events are recorded immediately.
+type spanRepeats struct {
+	mu      sync.Mutex
+	maxKeys int
+	keys    map[repeatKey]*repeatedEvent
+	events  []*repeatedEvent
+}
+
+// newSpanRepeats creates the repeat state for a span.
+func newSpanRepeats(maxKeys int) *spanRepeats {
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxRepeatKeys
+	}
+
+	return &spanRepeats{
+		maxKeys: maxKeys,
+		keys:    make(map[repeatKey]*repeatedEvent),
+	}
+}
+
+// repeat reports whether the event was already logged on the span, and counts it if so.
+func (r *spanRepeats) repeat(key repeatKey, t time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, ok := r.keys[key]
+	if !ok {
+		return false
+	}
+
+	event.count++
+	event.last = t
+	return true
+}
+
+// hold keeps the first occurrence of an event so that its repeats can be counted.
+// It reports false if the span already holds the maximum number of distinct events.
+func (r *spanRepeats) hold(key repeatKey, name string, attrs []attribute.KeyValue, t time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.keys) >= r.maxKeys {
+		return false
+	}
+
+	event := &repeatedEvent{name: name, attrs: attrs, first: t, last: t, count: 1}
+	r.keys[key] = event
+	r.events = append(r.events, event)
+	return true
+}
+
+// flush records a summary event on the span for each held event that was repeated, in the order
+// they were first logged. The summary carries the attributes of the event, the repeat count,
+// and the first and last times, and is timestamped with the last time.
+func (r *spanRepeats) flush(span trace.Span) {
+	r.mu.Lock()
+	events := r.events
+	r.events, r.keys = nil, make(map[repeatKey]*repeatedEvent)
+	r.mu.Unlock()
+
+	for _, event := range events {
+		if event.count < 2 {
+			continue
+		}
+
+		attrs := append(slices.Clip(event.attrs),
+			attribute.Int64(RepeatCountKey, event.count-1),
+			attribute.String(FirstTimeKey, event.first.Format(time.RFC3339Nano)),
+			attribute.String(LastTimeKey, event.last.Format(time.RFC3339Nano)))
+
+		opts := []trace.EventOption{trace.WithAttributes(attrs...)}
+		if !event.last.IsZero() {
+			opts = append(opts, trace.WithTimestamp(event.last))
+		}
+		span.AddEvent(event.name, opts...)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewRepeatKey(t *testing.T) {
+	attrs := []attribute.KeyValue{attribute.String("key", "value"), attribute.Int("n", 1)}
+
+	assert.Equal(t, newRepeatKey("log", "msg", attrs), newRepeatKey("log", "msg", attrs))
+	assert.NotEqual(t, newRepeatKey("log", "msg", attrs), newRepeatKey("log", "other", attrs))
+	assert.NotEqual(t, newRepeatKey("log", "msg", attrs), newRepeatKey("log", "msg", attrs[:1]))
+	assert.NotEqual(t,
+		newRepeatKey("log", "msg", []attribute.KeyValue{attribute.String("n", "1")}),
+		newRepeatKey("log", "msg", []attribute.KeyValue{attribute.Int("n", 1)}))
+}
+
+func TestHandlerCollapseRepeats(t *testing.T) {
+	setup := func(opts ...Options) (*tracetest.SpanRecorder, *bytes.Buffer, slog.Handler) {
+		spanRecorder := tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(trace.NewTracerProvider(trace.WithSpanProcessor(spanRecorder)))
+		buf := bytes.NewBuffer(nil)
+		return spanRecorder, buf, NewHandler(slog.NewJSONHandler(buf, nil), opts...)
+	}
+
+	logRecord := func(h slog.Handler, ctx context.Context, t time.Time, msg string, args ...any) {
+		record := slog.NewRecord(t, slog.LevelInfo, msg, 0)
+		record.Add(args...)
+		_ = h.Handle(ctx, record)
+	}
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("collapse", func(t *testing.T) {
+		spanRecorder, buf, h := setup(WithCollapseRepeats(0))
+
+		spanCtx := NewSpanContext("span", "trace")
+		logRecord(h, spanCtx, t0, "retry", "attempt", "x")
+		logRecord(h, spanCtx, t0.Add(time.Second), "other")
+		logRecord(h, spanCtx, t0.Add(2*time.Second), "retry", "attempt", "x")
+		logRecord(h, spanCtx, t0.Add(3*time.Second), "retry", "attempt", "x")
+		logRecord(h, spanCtx, t0.Add(4*time.Second), "retry", "attempt", "y")
+		spanCtx.End()
+
+		assert.Equal(t, 4, bytes.Count(buf.Bytes(), []byte(`"msg":"retry"`)))
+
+		spans := spanRecorder.Ended()
+
+		assert.Equal(t, 1, len(spans))
+		events := spans[0].Events()
+		assert.Equal(t, 4, len(events))
+		assert.Equal(t, t0, events[0].Time)
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String("attempt", "x"),
+			attribute.String(slog.MessageKey, "retry"),
+			attribute.String(slog.LevelKey, "INFO"),
+		}, events[0].Attributes)
+		assert.Contains(t, events[1].Attributes, attribute.String(slog.MessageKey, "other"))
+		assert.NotContains(t, events[1].Attributes, attribute.Key(RepeatCountKey).Int64(0))
+		assert.Contains(t, events[2].Attributes, attribute.String("attempt", "y"))
+		assert.NotContains(t, events[2].Attributes, attribute.Key(RepeatCountKey).Int64(0))
+		assert.Equal(t, t0.Add(3*time.Second), events[3].Time)
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String("attempt", "x"),
+			attribute.String(slog.MessageKey, "retry"),
+			attribute.String(slog.LevelKey, "INFO"),
+			attribute.Int64(RepeatCountKey, 2),
+			attribute.String(FirstTimeKey, "2024-01-01T00:00:00Z"),
+			attribute.String(LastTimeKey, "2024-01-01T00:00:03Z"),
+		}, events[3].Attributes)
+	})
+
+	t.Run("span ended elsewhere", func(t *testing.T) {
+		spanRecorder, _, h := setup(WithCollapseRepeats(0))
+
+		spanCtx := NewSpanContext("span", "trace")
+		logRecord(h, spanCtx, t0, "retry")
+		logRecord(h, spanCtx, t0, "retry")
+		logRecord(h, spanCtx, t0, "other")
+		spanCtx.Span.End()
+
+		spans := spanRecorder.Ended()
+
+		assert.Equal(t, 1, len(spans))
+		events := spans[0].Events()
+		assert.Equal(t, 2, len(events))
+		assert.Contains(t, events[0].Attributes, attribute.String(slog.MessageKey, "retry"))
+		assert.Contains(t, events[1].Attributes, attribute.String(slog.MessageKey, "other"))
+	})
+
+	t.Run("suppress", func(t *testing.T) {
+		_, buf, h := setup(WithCollapseRepeats(0), WithSuppressRepeats())
+
+		spanCtx := NewSpanContext("span", "trace")
+		for i := 0; i < 5; i++ {
+			logRecord(h, spanCtx, t0, "poll")
+		}
+		spanCtx.End()
+
+		assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte(`"msg":"poll"`)))
+	})
+
+	t.Run("max keys", func(t *testing.T) {
+		spanRecorder, _, h := setup(WithCollapseRepeats(1))
+
+		spanCtx := NewSpanContext("span", "trace")
+		logRecord(h, spanCtx, t0, "first")
+		logRecord(h, spanCtx, t0, "second")
+		logRecord(h, spanCtx, t0, "second")
+		logRecord(h, spanCtx, t0, "first")
+		spanCtx.End()
+
+		spans := spanRecorder.Ended()
+
+		assert.Equal(t, 1, len(spans))
+		events := spans[0].Events()
+		assert.Equal(t, 4, len(events))
+		assert.Contains(t, events[0].Attributes, attribute.String(slog.MessageKey, "first"))
+		assert.Contains(t, events[1].Attributes, attribute.String(slog.MessageKey, "second"))
+		assert.Contains(t, events[2].Attributes, attribute.String(slog.MessageKey, "second"))
+		assert.Contains(t, events[3].Attributes, attribute.String(slog.MessageKey, "first"))
+		assert.Contains(t, events[3].Attributes, attribute.Int64(RepeatCountKey, 1))
+	})
+}
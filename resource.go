@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// defaultResourceKeys are the resource attributes added to records when WithResource is given no keys.
+var defaultResourceKeys = []attribute.Key{
+	semconv.ServiceNameKey,
+	semconv.ServiceVersionKey,
+	semconv.DeploymentEnvironmentKey,
+}
+
+// WithResource adds attributes of the OpenTelemetry resource, typically the one passed to
+// sdktrace.WithResource, to every record passed to the next handler. Only the given keys are added,
+// or service.name, service.version and deployment.environment if no keys are given.
+// The attributes are bound to the next handler once with WithAttrs, so they have no per-record cost.
+func WithResource(res *resource.Resource, keys ...attribute.Key) Options {
+	return func(h *Handler) {
+		if res == nil || h.Next == nil {
+			return
+		}
+
+		if len(keys) == 0 {
+			keys = defaultResourceKeys
+		}
+
+		attrs := make([]slog.Attr, 0, len(keys))
+		for _, key := range keys {
+			if value, ok := res.Set().Value(key); ok {
+				attrs = append(attrs, slog.Attr{Key: string(key), Value: attributeValue(value)})
+			}
+		}
+
+		if len(attrs) > 0 {
+			h.Next = h.Next.WithAttrs(attrs)
+		}
+	}
+}
+
+// attributeValue converts an OpenTelemetry attribute value to a slog.Value.
+func attributeValue(value attribute.Value) slog.Value {
+	switch value.Type() {
+	case attribute.BOOL:
+		return slog.BoolValue(value.AsBool())
+	case attribute.INT64:
+		return slog.Int64Value(value.AsInt64())
+	case attribute.FLOAT64:
+		return slog.Float64Value(value.AsFloat64())
+	case attribute.STRING:
+		return slog.StringValue(value.AsString())
+	default:
+		return slog.AnyValue(value.AsInterface())
+	}
+}
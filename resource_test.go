@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2024 yakumioto <yaku.mioto@gmail.com>
+ * All rights reserved.
+ */
+
+package otelslog
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+func TestWithResource(t *testing.T) {
+	res := resource.NewSchemaless(
+		semconv.ServiceName("service"),
+		semconv.ServiceVersion("1.0.0"),
+		semconv.DeploymentEnvironment("production"),
+		attribute.Int("host.cpus", 4),
+		attribute.StringSlice("host.tags", []string{"a", "b"}),
+	)
+
+	t.Run("default keys", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		logger := slog.New(NewHandler(slog.NewJSONHandler(buf, nil), WithResource(res)))
+		logger.WithGroup("group").Info("with resource", "key", "value")
+
+		assert.Contains(t, buf.String(),
+			`"service.name":"service","service.version":"1.0.0","deployment.environment":"production","group":{"key":"value"}`)
+		assert.NotContains(t, buf.String(), `"host.cpus"`)
+	})
+
+	t.Run("chosen keys", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		logger := slog.New(NewHandler(slog.NewJSONHandler(buf, nil),
+			WithResource(res, semconv.ServiceNameKey, "host.cpus", "host.tags", "missing")))
+		logger.Info("with resource")
+
+		assert.Contains(t, buf.String(), `"service.name":"service","host.cpus":4,"host.tags":["a","b"]}`)
+		assert.NotContains(t, buf.String(), `"service.version"`)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			slog.New(NewHandler(nil, WithResource(res))).Info("nil next")
+			slog.New(NewHandler(slog.NewJSONHandler(bytes.NewBuffer(nil), nil), WithResource(nil))).Info("nil resource")
+		})
+	})
+}